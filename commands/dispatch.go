@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"wa-bot/state"
+	"wa-bot/storage"
+	"wa-bot/utils"
+)
+
+// Dispatch routes an incoming message to the matching registered command,
+// or to the prompt handler of the user's pending state.
+func Dispatch(s *state.MessageState) {
+	settings := storage.DefaultGroupSettings(s.ChatJID.String())
+	if s.IsFromGroup {
+		var err error
+		settings, err = storage.GetGroupSettings(s.ChatJID.String())
+		if err != nil {
+			fmt.Println("Failed to get group settings:", err)
+		}
+		if !applyPrefix(s, settings.Prefix) {
+			return
+		}
+	}
+
+	name, args, isCommand := SplitCommand(s.MessageText)
+	cmd, exists := Lookup(name)
+
+	if s.IsFromGroup && !settings.Enabled && !(exists && cmd.Name == "group") {
+		return
+	}
+
+	if status := s.CheckUserState(); status != "" {
+		if isCommand && exists && cmd.Name == "cancel" {
+			cmd.Handler(s)
+			return
+		} else if isCommand {
+			s.ReplyT("common.busy")
+			return
+		}
+
+		if handler, ok := prompts[status]; ok {
+			handler(s)
+			return
+		}
+	}
+
+	if isCommand {
+		if !exists {
+			s.ReplyT("common.invalid_command")
+			return
+		}
+		if allowed, deniedMessage := cmd.Authorize(s, time.Now()); !allowed {
+			s.Reply(deniedMessage)
+			return
+		}
+		if s.IsFromGroup && utils.Contains(settings.DisabledCommands, cmd.Name) {
+			s.ReplyT("common.command_disabled")
+			return
+		}
+		if !cmd.argsRegex.MatchString(args) {
+			s.ReplyT("common.invalid_command")
+			return
+		}
+
+		cmd.Handler(s)
+		return
+	}
+
+	if s.IsFromGroup {
+		return
+	}
+
+	if s.UserRole == "COMMON" || s.UserRole == "USER" {
+		s.ReplyT("common.help_hint")
+	}
+}
+
+// applyPrefix rewrites a message using the group's custom prefix to the "!"
+// form handlers expect. It returns false when the message uses "!" while the
+// group has another prefix, so the message belongs to a different bot.
+func applyPrefix(s *state.MessageState, prefix string) bool {
+	if prefix == "" || prefix == "!" {
+		return true
+	}
+
+	if strings.HasPrefix(s.MessageText, prefix) {
+		s.MessageText = "!" + strings.TrimPrefix(s.MessageText, prefix)
+		return true
+	}
+
+	return !strings.HasPrefix(s.MessageText, "!")
+}
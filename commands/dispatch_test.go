@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"testing"
+
+	"wa-bot/i18n"
+	"wa-bot/state/statetest"
+	"wa-bot/storage"
+)
+
+func TestDispatch(t *testing.T) {
+	setupCommandTest(t)
+
+	tests := []struct {
+		name string
+		role string
+		text string
+		want string
+	}{
+		{"command", "COMMON", "!testecho", "echo !testecho"},
+		{"alias with args", "COMMON", "!te 42", "echo !te 42"},
+		{"args mismatch", "COMMON", "!testecho abc", i18n.T("en", "common.invalid_command")},
+		{"unknown command", "COMMON", "!nope", i18n.T("en", "common.invalid_command")},
+		{"role denied", "COMMON", "!testowner", i18n.T("en", "common.denied")},
+		{"role allowed", "OWNER", "!testowner", "owner"},
+		{"plain text", "COMMON", "hello", i18n.T("en", "common.help_hint")},
+		{"plain text from admin", "ADMIN", "hello", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := &statetest.FakeMessenger{}
+			Dispatch(newTestState(messenger, testUser, tt.role, tt.text))
+
+			texts := messenger.Texts()
+			if tt.want == "" {
+				if len(texts) != 0 {
+					t.Fatalf("replied %q", texts)
+				}
+				return
+			}
+			if len(texts) != 1 || texts[0] != tt.want {
+				t.Fatalf("replied %q, want %q", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchWhileBusy(t *testing.T) {
+	setupCommandTest(t)
+	messenger := &statetest.FakeMessenger{}
+
+	pending := newTestState(messenger, testUser, "COMMON", "")
+	pending.AddUserToState("TestPending", nil)
+	t.Cleanup(pending.ClearUserState)
+
+	steps := []struct {
+		text string
+		want string
+	}{
+		{"my answer", "prompt my answer"},
+		{"!testecho", i18n.T("en", "common.busy")},
+		{"!nope", i18n.T("en", "common.busy")},
+		{"!cancel", "canceled"},
+	}
+
+	for _, step := range steps {
+		Dispatch(newTestState(messenger, testUser, "COMMON", step.text))
+		texts := messenger.Texts()
+		if got := texts[len(texts)-1]; got != step.want {
+			t.Fatalf("%q: replied %q, want %q", step.text, got, step.want)
+		}
+	}
+}
+
+func TestDispatchInGroup(t *testing.T) {
+	setupCommandTest(t)
+
+	dotted := testGroup
+	dotted.User = "120363000000000002"
+	disabled := testGroup
+	disabled.User = "120363000000000003"
+	denied := testGroup
+	denied.User = "120363000000000004"
+
+	for _, settings := range []storage.GroupSettings{
+		{GroupJID: dotted.String(), Enabled: true, Prefix: "."},
+		{GroupJID: disabled.String(), Enabled: false, Prefix: "!"},
+		{GroupJID: denied.String(), Enabled: true, Prefix: "!", DisabledCommands: []string{"testecho"}},
+	} {
+		if err := storage.SaveGroupSettings(settings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		chat string
+		text string
+		want string
+	}{
+		{"default prefix", testGroup.User, "!te 1", "echo !te 1"},
+		{"plain text is ignored", testGroup.User, "hello", ""},
+		{"custom prefix", dotted.User, ".te 1", "echo !te 1"},
+		{"bang in custom prefix group", dotted.User, "!te 1", ""},
+		{"disabled group", disabled.User, "!testecho", ""},
+		{"disabled command", denied.User, "!te", i18n.T("en", "common.command_disabled")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := testGroup
+			chat.User = tt.chat
+			messenger := &statetest.FakeMessenger{}
+			Dispatch(newTestState(messenger, chat, "COMMON", tt.text))
+
+			texts := messenger.Texts()
+			if tt.want == "" {
+				if len(texts) != 0 {
+					t.Fatalf("replied %q", texts)
+				}
+				return
+			}
+			if len(texts) != 1 || texts[0] != tt.want {
+				t.Fatalf("replied %q, want %q", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		text     string
+		wantText string
+		want     bool
+	}{
+		{"", "!help", "!help", true},
+		{"!", "!help", "!help", true},
+		{".", ".help", "!help", true},
+		{"#!", "#!help", "!help", true},
+		{".", "!help", "!help", false},
+		{".", "hello", "hello", true},
+	}
+
+	for _, tt := range tests {
+		s := newTestState(&statetest.FakeMessenger{}, testGroup, "COMMON", tt.text)
+		got := applyPrefix(s, tt.prefix)
+		if got != tt.want || s.MessageText != tt.wantText {
+			t.Errorf("applyPrefix(%q, %q) = %v, %q, want %v, %q", tt.prefix, tt.text, got, s.MessageText, tt.want, tt.wantText)
+		}
+	}
+}
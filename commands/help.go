@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"strings"
+
+	"wa-bot/state"
+)
+
+func init() {
+	Register(&Command{
+		Name:     "help",
+		Category: "help.cat_general",
+		Usage:    []string{"help.help"},
+		Handler:  HelpHandler,
+	})
+}
+
+func HelpHandler(s *state.MessageState) {
+	s.Reply(BuildHelp(s))
+}
+
+// BuildHelp renders the command list visible to the sender in their
+// language, grouped by category in registration order.
+func BuildHelp(s *state.MessageState) string {
+	var categories []string
+	byCategory := make(map[string][]*Command)
+
+	for _, cmd := range ordered {
+		if !cmd.IsAllowed(s) || len(cmd.Usage) == 0 {
+			continue
+		}
+		if _, seen := byCategory[cmd.Category]; !seen {
+			categories = append(categories, cmd.Category)
+		}
+		byCategory[cmd.Category] = append(byCategory[cmd.Category], cmd)
+	}
+
+	var b strings.Builder
+	b.WriteString(s.T("help.title"))
+
+	for _, category := range categories {
+		b.WriteString("\n\n*" + s.T(category) + "*")
+		for _, cmd := range byCategory[category] {
+			for _, usage := range cmd.Usage {
+				b.WriteString("\n- " + s.T(usage))
+			}
+		}
+		for _, cmd := range byCategory[category] {
+			if cmd.Details != "" {
+				b.WriteString("\n\n" + s.T(cmd.Details))
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"wa-bot/i18n"
+	"wa-bot/state/statetest"
+)
+
+func TestBuildHelpFiltersRoles(t *testing.T) {
+	tests := []struct {
+		role      string
+		wantOwner bool
+	}{
+		{"COMMON", false},
+		{"ADMIN", false},
+		{"OWNER", true},
+	}
+
+	for _, tt := range tests {
+		help := BuildHelp(newTestState(&statetest.FakeMessenger{}, testUser, tt.role, "!help"))
+
+		if !strings.Contains(help, "*Test*\n- `!testecho [n]`") {
+			t.Errorf("%s: help lacks the open command:\n%s", tt.role, help)
+		}
+		if got := strings.Contains(help, "!testowner"); got != tt.wantOwner {
+			t.Errorf("%s: owner command listed=%v", tt.role, got)
+		}
+		if got := strings.Contains(help, "*Test Owner*"); got != tt.wantOwner {
+			t.Errorf("%s: owner category listed=%v", tt.role, got)
+		}
+		if got := strings.Contains(help, "\n\nOwner only details"); got != tt.wantOwner {
+			t.Errorf("%s: owner details listed=%v", tt.role, got)
+		}
+		if strings.Contains(help, "!cancel") {
+			t.Errorf("%s: command without usage listed", tt.role)
+		}
+	}
+}
+
+func TestBuildHelpFollowsLanguage(t *testing.T) {
+	for _, lang := range []string{"en", "id"} {
+		s := newTestState(&statetest.FakeMessenger{}, testUser, "COMMON", "!help")
+		s.Language = lang
+
+		help := BuildHelp(s)
+		for _, key := range []string{"help.title", "help.cat_general", "help.help"} {
+			if !strings.Contains(help, i18n.T(lang, key)) {
+				t.Errorf("%s: help lacks %s:\n%s", lang, key, help)
+			}
+		}
+		if strings.Contains(help, "help.") {
+			t.Errorf("%s: help shows a catalog key:\n%s", lang, help)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"wa-bot/state"
+	"wa-bot/utils"
+)
+
+// Policy overrides the roles, chats and time windows a command may be used
+// in. It is loaded from a JSON file (ACL_FILE, default acl.json); commands
+// missing from the file keep the defaults they registered with.
+type Policy struct {
+	DeniedMessage string                   `json:"denied_message"`
+	Commands      map[string]CommandPolicy `json:"commands"`
+}
+
+type CommandPolicy struct {
+	Roles         []string `json:"roles"`
+	Chats         []string `json:"chats"`
+	Windows       []string `json:"windows"`
+	DeniedMessage string   `json:"denied_message"`
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   = &Policy{}
+)
+
+func init() {
+	Register(&Command{
+		Name:     "reloadacl",
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.reloadacl"},
+		Handler:  ReloadPolicyHandler,
+	})
+}
+
+func policyPath() string {
+	if path := os.Getenv("ACL_FILE"); path != "" {
+		return path
+	}
+	return "acl.json"
+}
+
+// LoadPolicy reads the policy file. A missing file is not an error and simply
+// resets every command to its registered defaults.
+func LoadPolicy() error {
+	loaded := &Policy{}
+
+	data, err := os.ReadFile(policyPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, loaded); err != nil {
+			return fmt.Errorf("invalid policy file: %v", err)
+		}
+	}
+
+	normalized := make(map[string]CommandPolicy, len(loaded.Commands))
+	for name, cmdPolicy := range loaded.Commands {
+		for _, window := range cmdPolicy.Windows {
+			if _, _, err := parseWindow(window); err != nil {
+				return fmt.Errorf("invalid window %q for %s: %v", window, name, err)
+			}
+		}
+		normalized[strings.ToLower(name)] = cmdPolicy
+	}
+	loaded.Commands = normalized
+
+	policyMu.Lock()
+	policy = loaded
+	policyMu.Unlock()
+
+	return nil
+}
+
+func ReloadPolicyHandler(s *state.MessageState) {
+	if err := LoadPolicy(); err != nil {
+		fmt.Println("Error reloading policy:", err)
+		s.ReplyT("policy.reload_failed", err.Error())
+		return
+	}
+	s.ReplyT("policy.reloaded")
+}
+
+// Authorize checks the sender against the command's policy and returns the
+// message to reply with when access is denied.
+func (c *Command) Authorize(s *state.MessageState, now time.Time) (bool, string) {
+	policyMu.RLock()
+	cmdPolicy, hasPolicy := policy.Commands[c.Name]
+	deniedMessage := policy.DeniedMessage
+	policyMu.RUnlock()
+
+	if hasPolicy && cmdPolicy.DeniedMessage != "" {
+		deniedMessage = cmdPolicy.DeniedMessage
+	}
+	if deniedMessage == "" {
+		deniedMessage = s.T("common.denied")
+	}
+
+	roles := c.Roles
+	if hasPolicy && len(cmdPolicy.Roles) > 0 {
+		roles = cmdPolicy.Roles
+	}
+	if len(roles) > 0 && !utils.Contains(roles, s.UserRole) {
+		return false, deniedMessage
+	}
+
+	if hasPolicy && len(cmdPolicy.Chats) > 0 {
+		if !chatAllowed(cmdPolicy.Chats, s) {
+			return false, deniedMessage
+		}
+	} else if (c.GroupOnly && !s.IsFromGroup) || (c.DMOnly && s.IsFromGroup) {
+		return false, deniedMessage
+	}
+
+	if hasPolicy && len(cmdPolicy.Windows) > 0 && !inWindows(cmdPolicy.Windows, now) {
+		return false, deniedMessage
+	}
+
+	return true, ""
+}
+
+func chatAllowed(chats []string, s *state.MessageState) bool {
+	for _, chat := range chats {
+		switch strings.ToLower(chat) {
+		case "dm":
+			if !s.IsFromGroup {
+				return true
+			}
+		case "group":
+			if s.IsFromGroup {
+				return true
+			}
+		default:
+			if chat == s.ChatJID.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inWindows reports whether now falls in any "HH:MM-HH:MM" window. Windows
+// whose end is before their start wrap around midnight.
+func inWindows(windows []string, now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+
+	for _, window := range windows {
+		start, end, err := parseWindow(window)
+		if err != nil {
+			continue
+		}
+		if start <= end && minute >= start && minute < end {
+			return true
+		}
+		if start > end && (minute >= start || minute < end) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWindow(window string) (int, int, error) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("expected HH:MM-HH:MM")
+	}
+
+	start, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
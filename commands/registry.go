@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"wa-bot/state"
+)
+
+type Handler func(s *state.MessageState)
+
+// Command describes a single chat command. Handlers register themselves from
+// an init function so that adding a command never requires touching main.go.
+// Category, Usage and Details are catalog keys, so !help follows the
+// sender's language.
+type Command struct {
+	Name      string
+	Aliases   []string
+	Args      string
+	Roles     []string
+	GroupOnly bool
+	DMOnly    bool
+	Category  string
+	Usage     []string
+	Details   string
+	Handler   Handler
+
+	argsRegex *regexp.Regexp
+}
+
+var (
+	registry = make(map[string]*Command)
+	ordered  []*Command
+	prompts  = make(map[string]Handler)
+)
+
+func Register(cmd *Command) {
+	if cmd.Name == "" || cmd.Handler == nil {
+		panic("commands: command must have a name and a handler")
+	}
+
+	cmd.argsRegex = regexp.MustCompile(`^(?:` + cmd.Args + `)$`)
+
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		name = strings.ToLower(name)
+		if _, exists := registry[name]; exists {
+			panic(fmt.Sprintf("commands: %q registered twice", name))
+		}
+		registry[name] = cmd
+	}
+
+	ordered = append(ordered, cmd)
+}
+
+// RegisterPrompt binds a handler to a user state, so plain text sent while the
+// user is in that state (e.g. "PendingToken") is routed to it.
+func RegisterPrompt(status string, handler Handler) {
+	prompts[status] = handler
+}
+
+func Lookup(name string) (*Command, bool) {
+	cmd, exists := registry[strings.ToLower(name)]
+	return cmd, exists
+}
+
+func All() []*Command {
+	return ordered
+}
+
+func (c *Command) IsAllowed(s *state.MessageState) bool {
+	allowed, _ := c.Authorize(s, time.Now())
+	return allowed
+}
+
+// SplitCommand splits "!name args" into its lowercase name and the raw
+// remainder (including the leading whitespace, so Args patterns can match it).
+func SplitCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "!") {
+		return "", "", false
+	}
+
+	body := strings.TrimPrefix(text, "!")
+	end := strings.IndexFunc(body, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if end == -1 {
+		return strings.ToLower(body), "", true
+	}
+
+	return strings.ToLower(body[:end]), body[end:], true
+}
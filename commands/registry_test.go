@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"wa-bot/state"
+	"wa-bot/state/statetest"
+	"wa-bot/storage"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	testGroup = waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	testUser  = waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+)
+
+// The test commands reply with what they were called with, so a test can
+// tell which handler ran and what text it saw.
+func init() {
+	Register(&Command{
+		Name:     "testecho",
+		Aliases:  []string{"te"},
+		Args:     `(\s+\d+)?`,
+		Category: "Test",
+		Usage:    []string{"`!testecho [n]`"},
+		Handler:  func(s *state.MessageState) { s.Reply("echo " + s.MessageText) },
+	})
+	Register(&Command{
+		Name:     "testowner",
+		Roles:    []string{"OWNER"},
+		Category: "Test Owner",
+		Usage:    []string{"`!testowner`"},
+		Details:  "Owner only details",
+		Handler:  func(s *state.MessageState) { s.Reply("owner") },
+	})
+	Register(&Command{
+		Name:    "cancel",
+		Handler: func(s *state.MessageState) { s.Reply("canceled") },
+	})
+	RegisterPrompt("TestPending", func(s *state.MessageState) { s.Reply("prompt " + s.MessageText) })
+}
+
+// setupCommandTest opens a fresh database, which group settings are read
+// from on every group message.
+func setupCommandTest(t *testing.T) {
+	t.Helper()
+	if err := storage.Open("file:" + filepath.Join(t.TempDir(), "bot.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.DB.Close() })
+}
+
+func newTestState(messenger *statetest.FakeMessenger, chat waTypes.JID, role, text string) *state.MessageState {
+	msg := &waProto.Message{Conversation: proto.String(text)}
+	in := &state.InboundMessage{
+		ID:          "TEST",
+		ChatJID:     chat,
+		SenderJID:   testUser,
+		IsFromGroup: chat.Server == waTypes.GroupServer,
+		Message:     msg,
+		Text:        text,
+	}
+	return &state.MessageState{
+		Messenger:   messenger,
+		Inbound:     in,
+		VMessage:    msg,
+		ChatJID:     chat,
+		SenderJID:   testUser,
+		MessageText: text,
+		IsFromGroup: in.IsFromGroup,
+		UserRole:    role,
+		Language:    "en",
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantName  string
+		wantArgs  string
+		isCommand bool
+	}{
+		{"!help", "help", "", true},
+		{"!Sticker nocrop", "sticker", " nocrop", true},
+		{"!pdf\nline one\nline two", "pdf", "\nline one\nline two", true},
+		{"!grant\t6281 ADMIN", "grant", "\t6281 ADMIN", true},
+		{"!", "", "", true},
+		{"hello !help", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		name, args, isCommand := SplitCommand(tt.text)
+		if name != tt.wantName || args != tt.wantArgs || isCommand != tt.isCommand {
+			t.Errorf("SplitCommand(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.text, name, args, isCommand, tt.wantName, tt.wantArgs, tt.isCommand)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"testecho", "TestEcho", "te", "TE"} {
+		cmd, exists := Lookup(name)
+		if !exists || cmd.Name != "testecho" {
+			t.Errorf("Lookup(%q) = %v, %v", name, cmd, exists)
+		}
+	}
+	if _, exists := Lookup("testech"); exists {
+		t.Error("found a command by prefix")
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering an alias twice did not panic")
+		}
+	}()
+	Register(&Command{Name: "testother", Aliases: []string{"te"}, Handler: func(*state.MessageState) {}})
+}
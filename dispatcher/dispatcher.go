@@ -0,0 +1,154 @@
+package dispatcher
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Job func()
+
+// Dispatcher runs jobs on one worker goroutine per key (chat JID), so jobs for
+// the same chat keep their order while different chats run in parallel.
+// Queues are bounded: when a chat's queue is full the job is dropped instead
+// of blocking the caller, which is whatsmeow's event goroutine.
+type Dispatcher struct {
+	mu          sync.Mutex
+	workers     map[string]*worker
+	queueSize   int
+	idleTimeout time.Duration
+
+	enqueued  atomic.Uint64
+	dropped   atomic.Uint64
+	processed atomic.Uint64
+	panicked  atomic.Uint64
+	queued    atomic.Int64
+	maxQueued atomic.Int64
+	waitNanos atomic.Int64
+}
+
+type worker struct {
+	jobs chan queuedJob
+}
+
+type queuedJob struct {
+	run        Job
+	enqueuedAt time.Time
+}
+
+type Stats struct {
+	Enqueued      uint64  `json:"enqueued"`
+	Dropped       uint64  `json:"dropped"`
+	Processed     uint64  `json:"processed"`
+	Panicked      uint64  `json:"panicked"`
+	Queued        int64   `json:"queued"`
+	MaxQueued     int64   `json:"max_queued"`
+	ActiveWorkers int     `json:"active_workers"`
+	AvgWaitMs     float64 `json:"avg_wait_ms"`
+}
+
+func New(queueSize int, idleTimeout time.Duration) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = 32
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = time.Minute
+	}
+
+	return &Dispatcher{
+		workers:     make(map[string]*worker),
+		queueSize:   queueSize,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Submit queues job on the worker for key without blocking. It returns false
+// when the key's queue is full and the job was dropped.
+func (d *Dispatcher) Submit(key string, job Job) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, exists := d.workers[key]
+	if !exists {
+		w = &worker{jobs: make(chan queuedJob, d.queueSize)}
+		d.workers[key] = w
+		go d.run(key, w)
+	}
+
+	select {
+	case w.jobs <- queuedJob{run: job, enqueuedAt: time.Now()}:
+		d.enqueued.Add(1)
+		queued := d.queued.Add(1)
+		for {
+			max := d.maxQueued.Load()
+			if queued <= max || d.maxQueued.CompareAndSwap(max, queued) {
+				break
+			}
+		}
+		return true
+	default:
+		d.dropped.Add(1)
+		fmt.Printf("Dispatch queue for %s is full, dropping message\n", key)
+		return false
+	}
+}
+
+func (d *Dispatcher) run(key string, w *worker) {
+	timer := time.NewTimer(d.idleTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case job := <-w.jobs:
+			d.queued.Add(-1)
+			d.waitNanos.Add(int64(time.Since(job.enqueuedAt)))
+			d.execute(key, job.run)
+			d.processed.Add(1)
+			timer.Reset(d.idleTimeout)
+
+		case <-timer.C:
+			d.mu.Lock()
+			if len(w.jobs) == 0 {
+				delete(d.workers, key)
+				d.mu.Unlock()
+				return
+			}
+			d.mu.Unlock()
+			timer.Reset(d.idleTimeout)
+		}
+	}
+}
+
+func (d *Dispatcher) execute(key string, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.panicked.Add(1)
+			fmt.Printf("Recovered panic while handling message in %s: %v\n%s", key, r, debug.Stack())
+		}
+	}()
+
+	job()
+}
+
+func (d *Dispatcher) Stats() Stats {
+	d.mu.Lock()
+	activeWorkers := len(d.workers)
+	d.mu.Unlock()
+
+	stats := Stats{
+		Enqueued:      d.enqueued.Load(),
+		Dropped:       d.dropped.Load(),
+		Processed:     d.processed.Load(),
+		Panicked:      d.panicked.Load(),
+		Queued:        d.queued.Load(),
+		MaxQueued:     d.maxQueued.Load(),
+		ActiveWorkers: activeWorkers,
+	}
+	if stats.Processed > 0 {
+		stats.AvgWaitMs = float64(d.waitNanos.Load()) / float64(stats.Processed) / float64(time.Millisecond)
+	}
+
+	return stats
+}
@@ -0,0 +1,138 @@
+package dispatcher
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubmitKeepsOrderWithinKey(t *testing.T) {
+	d := New(16, time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var order []int
+	d.Submit("chat", func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// Everything below queues up behind the blocked job.
+	for i := range 10 {
+		d.Submit("chat", func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+	close(release)
+
+	waitFor(t, "all jobs", func() bool { return d.Stats().Processed == 11 })
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(order, want) {
+		t.Fatalf("ran in order %v", order)
+	}
+}
+
+func TestSubmitRunsKeysInParallel(t *testing.T) {
+	d := New(16, time.Minute)
+	bRan := make(chan struct{})
+	aDone := make(chan bool, 1)
+
+	// a only finishes if b runs while a is still blocked.
+	d.Submit("a", func() {
+		select {
+		case <-bRan:
+			aDone <- true
+		case <-time.After(time.Second):
+			aDone <- false
+		}
+	})
+	d.Submit("b", func() { close(bRan) })
+
+	if !<-aDone {
+		t.Fatal("b waited for a")
+	}
+	if got := d.Stats().ActiveWorkers; got != 2 {
+		t.Errorf("%d active workers, want 2", got)
+	}
+}
+
+func TestSubmitDropsWhenQueueIsFull(t *testing.T) {
+	d := New(2, time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	d.Submit("chat", func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	results := []bool{
+		d.Submit("chat", func() {}),
+		d.Submit("chat", func() {}),
+		d.Submit("chat", func() {}),
+	}
+	if want := []bool{true, true, false}; !slices.Equal(results, want) {
+		t.Fatalf("Submit returned %v, want %v", results, want)
+	}
+	if !d.Submit("other", func() {}) {
+		t.Fatal("a full queue dropped a job for another key")
+	}
+	close(release)
+
+	waitFor(t, "all jobs", func() bool { return d.Stats().Processed == 4 })
+	// MaxQueued counts every key, so the job for "other" may add to it.
+	stats := d.Stats()
+	if stats.Enqueued != 4 || stats.Dropped != 1 || stats.Queued != 0 || stats.MaxQueued < 2 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestPanicDoesNotStopWorker(t *testing.T) {
+	d := New(4, time.Minute)
+	ran := make(chan struct{})
+
+	d.Submit("chat", func() { panic("boom") })
+	d.Submit("chat", func() { close(ran) })
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("the job after a panic did not run")
+	}
+	waitFor(t, "stats", func() bool { return d.Stats().Processed == 2 })
+	if got := d.Stats().Panicked; got != 1 {
+		t.Errorf("%d panics counted, want 1", got)
+	}
+}
+
+func TestIdleWorkerExits(t *testing.T) {
+	d := New(4, 10*time.Millisecond)
+
+	d.Submit("chat", func() {})
+	waitFor(t, "idle worker to exit", func() bool { return d.Stats().ActiveWorkers == 0 })
+
+	ran := make(chan struct{})
+	d.Submit("chat", func() { close(ran) })
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("no worker was started again for the key")
+	}
+}
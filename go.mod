@@ -13,6 +13,7 @@ require (
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/rs/cors v1.11.1
 	go.mau.fi/whatsmeow v0.0.0-20250402091807-b0caa1b76088
+	golang.org/x/image v0.27.0
 	google.golang.org/api v0.234.0
 	google.golang.org/protobuf v1.36.6
 )
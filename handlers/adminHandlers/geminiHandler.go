@@ -12,6 +12,7 @@ import (
 	"github.com/joho/godotenv"
 	"google.golang.org/api/option"
 
+	"wa-bot/commands"
 	"wa-bot/state"
 	"wa-bot/utils"
 )
@@ -21,6 +22,15 @@ func init() {
 	if err != nil {
 		fmt.Println("Error loading .env file")
 	}
+
+	commands.Register(&commands.Command{
+		Name:     "gemini",
+		Args:     `(\s+\S+)*`,
+		Roles:    []string{"ADMIN", "OWNER"},
+		Category: "help.cat_admin",
+		Usage:    []string{"help.gemini"},
+		Handler:  GeminiHandler,
+	})
 }
 
 func sanitizeFileName(name string) string {
@@ -35,22 +45,19 @@ func sanitizeFileName(name string) string {
 }
 
 func GeminiHandler(s *state.MessageState) {
-
-	isAllowed := s.UserRole == "ADMIN" || s.UserRole == "OWNER"
-
-	if !isAllowed {
-		s.Reply("Invalid Command")
+	parts := strings.Fields(s.MessageText)
+	if len(parts) < 2 {
+		s.ReplyT("common.invalid_command")
 		return
 	}
+	mapel := parts[1]
 
-	mapel := strings.Split(s.MessageText, " ")[1]
-
-	s.Reply("⏳ Loading...")
+	s.ReplyT("common.loading")
 
 	listMapel, err := utils.FetchMapel()
 	if err != nil {
 		utils.LogNoCancelErr(context.Background(), err, "Error fetching mapel:")
-		s.Reply("Gagal mengambil daftar mapel.")
+		s.ReplyT("mapel.fetch_failed")
 		return
 	}
 
@@ -58,11 +65,11 @@ func GeminiHandler(s *state.MessageState) {
 		if index > 0 && index <= len(listMapel) {
 			mapel = listMapel[index-1]
 		} else {
-			s.Reply("Nomor mapel tidak valid.")
+			s.ReplyT("mapel.invalid_number")
 			return
 		}
 	} else if !utils.Contains(listMapel, mapel) {
-		s.Reply("Mapel tidak valid.")
+		s.ReplyT("mapel.invalid")
 		return
 	}
 
@@ -78,7 +85,7 @@ func GeminiHandler(s *state.MessageState) {
 		defer os.Remove(pdfPath)
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error fetching PDF:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
@@ -123,7 +130,7 @@ func GeminiHandler(s *state.MessageState) {
 				uploadedFile, err = client.UploadFile(ctx, file_name, file, nil)
 				if err != nil {
 					fmt.Printf("Gagal mengunggah ulang file: %v\n", err)
-					s.Reply("Gagal mengunggah ulang file. Coba lagi")
+					s.ReplyT("gemini.upload_failed")
 					return
 				}
 			} else {
@@ -158,21 +165,21 @@ func GeminiHandler(s *state.MessageState) {
 		if utils.IsCanceledGoroutine(ctx) { return }
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error reading file:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
 		uploaded, err := s.UploadToWhatsapp(ctx, fileData, "document")
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error uploading file:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
-		err = s.SendDocumentMessage(ctx, uploaded, mapel)
+		err = s.SendDocumentMessage(ctx, uploaded, mapel+".pdf", "application/pdf")
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error sending document message:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 	}()
@@ -4,25 +4,46 @@ import (
 	"context"
 	"fmt"
 
+	"wa-bot/commands"
 	"wa-bot/state"
 	"wa-bot/utils"
 )
 
-func ListgroupsHandler(s *state.MessageState){
-	if s.UserRole != "OWNER" {
-		s.Reply("Invalid Command")
-		return
-	}
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "listgroups",
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.listgroups"},
+		Handler:  ListgroupsHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "refreshgroups",
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.refreshgroups"},
+		Handler:  RefreshGroupsHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "listmapel",
+		Roles:    []string{"ADMIN", "OWNER"},
+		Category: "help.cat_admin",
+		Usage:    []string{"help.listmapel"},
+		Handler:  ListMapelHandler,
+	})
+}
 
+func ListgroupsHandler(s *state.MessageState){
 	groups, err := s.Client.GetJoinedGroups()
 	if err != nil {
 		fmt.Println("Error fetching joined groups:", err)
+		s.ReplyT("groups.list_failed")
 		return
 	}
 
-	responseText := "📌 *Daftar Grup:*\n\n"
+	responseText := s.T("groups.list_title") + "\n\n"
 	for _, group := range groups {
-		responseText += fmt.Sprintf("📂 *%s*\n📎 ID: %s\n", group.Name, group.JID.String())
+		responseText += s.T("groups.list_item", group.Name, group.JID.String()) + "\n"
 
 		_, err :=  s.Client.GetGroupInfo(group.JID)
 		if err != nil {
@@ -34,23 +55,28 @@ func ListgroupsHandler(s *state.MessageState){
 	s.Reply(responseText)
 }
 
-func ListMapelHandler(s *state.MessageState) {
-	isAllowed := s.UserRole == "ADMIN" || s.UserRole == "OWNER"
-
-	if !isAllowed {
+func RefreshGroupsHandler(s *state.MessageState) {
+	err := utils.GroupCache.Load(s.Client)
+	if err != nil {
+		fmt.Println("Error refreshing group cache:", err)
+		s.ReplyT("groups.refresh_failed")
 		return
 	}
 
+	s.ReplyT("groups.refreshed", utils.GroupCache.Count())
+}
+
+func ListMapelHandler(s *state.MessageState) {
 	listMapel, err := utils.FetchMapel()
 	if err != nil {
 		utils.LogNoCancelErr(context.Background(), err, "Error fetching mapel:")
-		s.ReplyNoCancelError(context.Background(), err, "Gagal mengambil daftar mapel.")
+		s.ReplyNoCancelError(context.Background(), err, s.T("mapel.fetch_failed"))
 		return
 	}
 
 	var listMapelString string
 	for i, mapel := range listMapel {
-		listMapelString += fmt.Sprintf("%d. %s\n", i+1, mapel)
+		listMapelString += s.T("mapel.list_item", i+1, mapel) + "\n"
 	}
 
 	s.Reply(listMapelString)
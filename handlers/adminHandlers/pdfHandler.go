@@ -6,18 +6,37 @@ import (
 	"strconv"
 	"strings"
 
+	"wa-bot/commands"
 	"wa-bot/state"
 	"wa-bot/utils"
 )
 
-func SendPDFHandler(s *state.MessageState) {
-	isAllowed := s.UserRole == "ADMIN" || s.UserRole == "OWNER"
-
-	if !isAllowed {
-		s.Reply("Invalid Command")
-		return
-	}
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "pdf",
+		Args:     `\s+\S+`,
+		Roles:    []string{"ADMIN", "OWNER"},
+		Category: "help.cat_admin",
+		Usage: []string{
+			"help.pdf_number",
+			"help.pdf_name",
+		},
+		Handler: SendPDFHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "answer",
+		Args:     `(\s+\S+)*`,
+		Roles:    []string{"ADMIN", "OWNER"},
+		Category: "help.cat_admin",
+		Usage: []string{
+			"help.answer_number",
+			"help.answer_name",
+		},
+		Handler: SendPDFHandler,
+	})
+}
 
+func SendPDFHandler(s *state.MessageState) {
 	parts := strings.SplitN(s.MessageText, "\n", 2)
 	commandString := parts[0]
 	answerBody := ""
@@ -27,19 +46,19 @@ func SendPDFHandler(s *state.MessageState) {
 
 	commandArray := strings.Split(commandString, " ")
 	if len(commandArray) != 2 {
-		s.Reply("Format perintah salah")
+		s.ReplyT("pdf.bad_format")
 		return
 	}
 
 	command := commandArray[0]
 	mapel := commandArray[1]
 
-	s.Reply("⏳ Loading...")
+	s.ReplyT("common.loading")
 
 	listMapel, err := utils.FetchMapel()
 	if err != nil {
 		utils.LogNoCancelErr(context.Background(), err, "Error fetching mapel:")
-		s.Reply("Gagal mengambil daftar mapel.")
+		s.ReplyT("mapel.fetch_failed")
 		return
 	}
 
@@ -47,11 +66,11 @@ func SendPDFHandler(s *state.MessageState) {
 		if index > 0 && index <= len(listMapel) {
 			mapel = listMapel[index-1]
 		} else {
-			s.Reply("Nomor mapel tidak valid.")
+			s.ReplyT("mapel.invalid_number")
 			return
 		}
 	} else if !utils.Contains(listMapel, mapel) {
-		s.Reply("Mapel tidak valid.")
+		s.ReplyT("mapel.invalid")
 		return
 	}
 
@@ -74,7 +93,7 @@ func SendPDFHandler(s *state.MessageState) {
 		defer os.Remove(pdfPath)
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error fetching PDF:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
@@ -82,21 +101,21 @@ func SendPDFHandler(s *state.MessageState) {
 		if utils.IsCanceledGoroutine(ctx) { return }
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error reading file:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
 		uploaded, err := s.UploadToWhatsapp(ctx, fileData, "document")
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error uploading file:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 
-		err = s.SendDocumentMessage(ctx, uploaded, mapel)
+		err = s.SendDocumentMessage(ctx, uploaded, mapel+".pdf", "application/pdf")
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error sending document message:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mengambil PDF")
+			s.ReplyNoCancelError(ctx, err, s.T("pdf.failed"))
 			return
 		}
 	}()
@@ -0,0 +1,135 @@
+package adminHandlers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/storage"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "grant",
+		Args:     `(\s+\S+){2,}`,
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.grant"},
+		Handler:  GrantHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "revoke",
+		Args:     `(\s+\S+)+`,
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.revoke"},
+		Handler:  RevokeHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "roles",
+		Roles:    []string{"OWNER"},
+		Category: "help.cat_owner",
+		Usage:    []string{"help.roles"},
+		Handler:  RolesHandler,
+	})
+}
+
+// GrantHandler reads the arguments from the end, so that the number may be
+// written with spaces: a duration follows the role when there is one, and
+// everything before the role is the number.
+func GrantHandler(s *state.MessageState) {
+	args := strings.Fields(s.MessageText)[1:]
+
+	var expiresAt time.Time
+	if len(args) > 2 && utils.Contains(utils.Roles, strings.ToUpper(args[len(args)-2])) {
+		duration, err := utils.ParseDuration(args[len(args)-1])
+		if err != nil {
+			s.ReplyT("role.invalid_duration")
+			return
+		}
+		expiresAt = time.Now().Add(duration)
+		args = args[:len(args)-1]
+	}
+
+	role := strings.ToUpper(args[len(args)-1])
+	if !utils.Contains(utils.Roles, role) {
+		s.ReplyT("role.invalid_role")
+		return
+	}
+
+	targetJID, err := s.ResolveUserJID(strings.Join(args[:len(args)-1], " "))
+	if err != nil {
+		s.ReplyT("common.invalid_number")
+		return
+	}
+
+	err = storage.GrantRole(targetJID.String(), role, s.SenderJID.String(), expiresAt)
+	if err != nil {
+		fmt.Println("Error granting role:", err)
+		s.ReplyT("role.grant_failed")
+		return
+	}
+
+	if expiresAt.IsZero() {
+		s.ReplyT("role.granted", targetJID.User, role)
+	} else {
+		s.ReplyT("role.granted_until", targetJID.User, role, expiresAt.Format("2006-01-02 15:04"))
+	}
+}
+
+func RevokeHandler(s *state.MessageState) {
+	number := strings.Join(strings.Fields(s.MessageText)[1:], " ")
+
+	targetJID, err := s.ResolveUserJID(number)
+	if err != nil {
+		s.ReplyT("common.invalid_number")
+		return
+	}
+
+	revoked, err := storage.RevokeRole(targetJID.String())
+	if err != nil {
+		fmt.Println("Error revoking role:", err)
+		s.ReplyT("role.revoke_failed")
+		return
+	}
+	if !revoked {
+		s.ReplyT("role.not_granted", targetJID.User)
+		return
+	}
+
+	s.ReplyT("role.revoked", targetJID.User)
+}
+
+func RolesHandler(s *state.MessageState) {
+	grants, err := storage.ListRoles()
+	if err != nil {
+		fmt.Println("Error listing roles:", err)
+		s.ReplyT("role.list_failed")
+		return
+	}
+
+	responseText := s.T("role.owners_title") + "\n"
+	for _, owner := range strings.Split(os.Getenv("OWNER_JID"), ",") {
+		if owner = strings.TrimSpace(owner); owner != "" {
+			responseText += s.T("role.owner_item", strings.Split(owner, "@")[0]) + "\n"
+		}
+	}
+
+	responseText += "\n" + s.T("role.grants_title") + "\n"
+	if len(grants) == 0 {
+		responseText += s.T("role.grants_none") + "\n"
+	}
+	for _, grant := range grants {
+		line := s.T("role.grant_item", strings.Split(grant.JID, "@")[0], grant.Role)
+		if !grant.ExpiresAt.IsZero() {
+			line += s.T("role.until", grant.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+		responseText += line + "\n"
+	}
+
+	s.Reply(strings.TrimSpace(responseText))
+}
@@ -6,28 +6,34 @@ import (
 	"os"
 	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
+	"wa-bot/commands"
 	"wa-bot/state"
 	"wa-bot/utils"
-)
 
-func TokenHandler(s *state.MessageState) {
-	isAllowed := s.UserRole == "ADMIN" || s.UserRole == "OWNER" || s.UserRole == "USER"
-	isFromGroup := s.IsFromGroup
+	waTypes "go.mau.fi/whatsmeow/types"
+)
 
-	if !isAllowed || isFromGroup {
-		s.Reply("Invalid Command")
-		return
-	}
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "token",
+		Roles:    []string{"ADMIN", "OWNER", "USER"},
+		DMOnly:   true,
+		Category: "help.cat_user",
+		Usage:    []string{"help.token"},
+		Handler:  TokenHandler,
+	})
+	commands.RegisterPrompt("PendingToken", GetNameHandler)
+}
 
+func TokenHandler(s *state.MessageState) {
 	s.AddUserToState("PendingToken", func() {});
-	s.Reply("Silakan masukkan nama lengkap Anda.")
+	s.ReplyT("token.ask_name")
 }
 
 func GetNameHandler(s *state.MessageState) {
-	s.Reply("⏳ Loading...")
+	s.ReplyT("common.loading")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	s.UpdateUserProcess(cancel)
@@ -50,32 +56,37 @@ func GetNameHandler(s *state.MessageState) {
 		}
 
 		if time.Since(startTime) > time.Duration(timeout)*time.Minute {
-			s.Reply("⏳ Waktu habis! Silakan ketik *!token* lagi.")
+			s.ReplyT("token.timeout")
 			return
 		}
 
 		var validNameRegex = regexp.MustCompile(`^[a-zA-Z' ]+$`)
 
 		if !validNameRegex.MatchString(s.MessageText) {
-			s.Reply("⚠️ Nama Invalid")
+			s.ReplyT("token.invalid_name")
+			return
+		}
+
+		if s.SenderJID.Server != waTypes.DefaultUserServer {
+			s.ReplyT("token.unknown_number")
 			return
 		}
 
-		nis := strings.Split(s.SenderJID.String(), "@")[0]
+		nis := s.SenderJID.User
 		nama := s.MessageText
 
 		status, token, err := utils.FetchTokenData(ctx, nama, nis)
 		if err != nil {
 			utils.LogNoCancelErr(ctx, err, "Error fetching token data:")
-			s.ReplyNoCancelError(ctx, err, "Gagal mendapatkan token.")
+			s.ReplyNoCancelError(ctx, err, s.T("token.failed"))
 			return
 		}
 
 		var responseText string
 		if status == "new" {
-			responseText = "✅ Token baru Anda adalah:"
+			responseText = s.T("token.new")
 		} else if status == "update" {
-			responseText = "Token lama telah tidak berlaku. Ini token baru anda:"
+			responseText = s.T("token.updated")
 		}
 
 		if utils.IsCanceledGoroutine(ctx) { return }
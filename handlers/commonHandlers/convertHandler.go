@@ -0,0 +1,146 @@
+package commonHandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "toimg",
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.toimg"},
+		Handler:  ToImageHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "tovid",
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.tovid"},
+		Handler:  ToVideoHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "togif",
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.togif"},
+		Handler:  ToGIFHandler,
+	})
+}
+
+func ToImageHandler(s *state.MessageState) {
+	convertQuotedSticker(s, func(ctx context.Context, webpPath string) error {
+		pngPath, err := utils.ConvertWebpToPng(webpPath)
+		defer os.Remove(pngPath)
+		if err != nil {
+			return fmt.Errorf("convert to PNG: %w", err)
+		}
+
+		data, err := os.ReadFile(pngPath)
+		if err != nil {
+			return fmt.Errorf("read PNG: %w", err)
+		}
+
+		uploaded, err := s.UploadToWhatsapp(ctx, data, "image")
+		if err != nil {
+			return fmt.Errorf("upload to WhatsApp: %w", err)
+		}
+		return s.SendImageMessage(ctx, uploaded, "image/png")
+	})
+}
+
+func ToVideoHandler(s *state.MessageState) {
+	sendStickerAsVideo(s, false)
+}
+
+func ToGIFHandler(s *state.MessageState) {
+	sendStickerAsVideo(s, true)
+}
+
+func sendStickerAsVideo(s *state.MessageState, gifPlayback bool) {
+	if sticker := s.QuotedMessage().GetStickerMessage(); sticker != nil && !sticker.GetIsAnimated() {
+		s.ReplyT("sticker.not_animated")
+		return
+	}
+
+	convertQuotedSticker(s, func(ctx context.Context, webpPath string) error {
+		mp4Path, seconds, err := utils.ConvertWebpToMp4(ctx, webpPath)
+		defer os.Remove(mp4Path)
+		if err != nil {
+			return fmt.Errorf("convert to MP4: %w", err)
+		}
+
+		data, err := os.ReadFile(mp4Path)
+		if utils.IsCanceledGoroutine(ctx) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read MP4: %w", err)
+		}
+
+		uploaded, err := s.UploadToWhatsapp(ctx, data, "video")
+		if err != nil {
+			return fmt.Errorf("upload to WhatsApp: %w", err)
+		}
+		return s.SendVideoMessage(ctx, uploaded, seconds, gifPlayback)
+	})
+}
+
+// convertQuotedSticker downloads the quoted sticker and runs convert on it in
+// the background as the sender's running process.
+func convertQuotedSticker(s *state.MessageState, convert func(ctx context.Context, webpPath string) error) {
+	sticker := s.QuotedMessage().GetStickerMessage()
+	if sticker == nil {
+		s.ReplyT("sticker.reply_to_sticker")
+		return
+	}
+
+	s.ReplyT("common.loading")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddUserToState("processing", cancel)
+
+	go func() {
+		defer s.ClearUserState()
+		defer cancel()
+
+		data, err := s.Messenger.Download(sticker)
+		if err != nil {
+			fmt.Println("Error downloading sticker:", err)
+			s.ReplyT("sticker.invalid_media")
+			return
+		}
+
+		webpFile, err := os.CreateTemp("", "sticker-*.webp")
+		if err != nil {
+			fmt.Println("Error saving sticker:", err)
+			s.ReplyT("sticker.convert_failed")
+			return
+		}
+		defer os.Remove(webpFile.Name())
+		_, err = webpFile.Write(data)
+		if closeErr := webpFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Println("Error saving sticker:", err)
+			s.ReplyT("sticker.convert_failed")
+			return
+		}
+
+		err = convert(ctx, webpFile.Name())
+		if errors.Is(err, utils.ErrorNotAnimated) {
+			s.ReplyT("sticker.not_animated")
+		} else if err != nil {
+			utils.LogNoCancelErr(ctx, err, "error:")
+			s.ReplyNoCancelError(ctx, err, s.T("sticker.convert_failed"))
+		}
+	}()
+}
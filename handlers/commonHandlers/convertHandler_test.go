@@ -0,0 +1,70 @@
+package commonHandlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/state/statetest"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// quoteSticker makes s a reply to a sticker whose media the messenger can
+// download.
+func quoteSticker(s *state.MessageState, messenger *statetest.FakeMessenger, data []byte) {
+	sum := sha256.Sum256(data)
+	if messenger.Media == nil {
+		messenger.Media = make(map[string][]byte)
+	}
+	messenger.Media[hex.EncodeToString(sum[:])] = data
+	s.Inbound.Quoted = &waProto.Message{StickerMessage: &waProto.StickerMessage{
+		Mimetype:   proto.String("image/webp"),
+		FileSHA256: sum[:],
+	}}
+}
+
+func TestToImageInParallel(t *testing.T) {
+	sticker, err := os.ReadFile("../../utils/testdata/lossy.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupHandlerTest(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	messenger := &statetest.FakeMessenger{}
+
+	var jobs []*state.MessageState
+	for _, user := range []string{"6281200000001", "6281200000002", "6281200000003"} {
+		s := newTestState(messenger, testGroup, waTypes.NewJID(user, waTypes.DefaultUserServer), "!toimg", nil)
+		quoteSticker(s, messenger, sticker)
+		commands.Dispatch(s)
+		jobs = append(jobs, s)
+	}
+	for _, s := range jobs {
+		waitForJob(t, s)
+	}
+
+	images := 0
+	for _, sent := range messenger.Sent {
+		if sent.Message.GetImageMessage() != nil {
+			images++
+		}
+	}
+	if images != len(jobs) {
+		t.Fatalf("%d images for %d jobs, replies %q", images, len(jobs), messenger.Texts())
+	}
+
+	left, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("temporary files left: %v", left)
+	}
+}
@@ -0,0 +1,273 @@
+package commonHandlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state"
+	"wa-bot/storage"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "group",
+		Args:     `(\s+\S+)+`,
+		Category: "help.cat_group",
+		Usage: []string{
+			"help.group_settings",
+			"help.group_toggle",
+			"help.group_command",
+			"help.group_lang",
+			"help.group_prefix",
+			"help.group_quota",
+			"help.group_welcome",
+			"help.group_allow",
+		},
+		Handler: GroupHandler,
+	})
+}
+
+func GroupHandler(s *state.MessageState) {
+	args := strings.Fields(s.MessageText)[1:]
+	subcommand := strings.ToLower(args[0])
+
+	if subcommand == "allow" || subcommand == "deny" {
+		groupAllowHandler(s, subcommand == "allow", args[1:])
+		return
+	}
+
+	if !s.IsFromGroup {
+		s.ReplyT("common.group_only")
+		return
+	}
+
+	settings, err := storage.GetGroupSettings(s.ChatJID.String())
+	if err != nil {
+		fmt.Println("Error getting group settings:", err)
+		s.ReplyT("group.load_failed")
+		return
+	}
+
+	if subcommand == "settings" {
+		s.Reply(formatGroupSettings(s, settings))
+		return
+	}
+
+	if s.UserRole != "OWNER" && !utils.IsGroupAdmin(s.Client, s.ChatJID, s.SenderJID) {
+		s.ReplyT("group.admin_only")
+		return
+	}
+
+	var reply string
+	switch subcommand {
+	case "enable", "disable":
+		enable := subcommand == "enable"
+		if len(args) == 1 {
+			settings.Enabled = enable
+			reply = s.T(map[bool]string{true: "group.enabled", false: "group.disabled"}[enable])
+			break
+		}
+
+		name := strings.ToLower(strings.TrimPrefix(args[1], "!"))
+		cmd, exists := commands.Lookup(name)
+		if !exists || cmd.Name == "group" {
+			s.ReplyT("group.unknown_cmd", name)
+			return
+		}
+		settings.DisabledCommands = removeString(settings.DisabledCommands, cmd.Name)
+		if !enable {
+			settings.DisabledCommands = append(settings.DisabledCommands, cmd.Name)
+		}
+		reply = s.T(map[bool]string{true: "group.cmd_enabled", false: "group.cmd_disabled"}[enable], cmd.Name)
+
+	case "lang":
+		if len(args) < 2 || !i18n.IsSupported(args[1]) {
+			s.ReplyT("group.lang_usage", strings.Join(i18n.Languages(), "/"))
+			return
+		}
+		settings.Language = args[1]
+		reply = i18n.T(args[1], "group.lang_set", args[1])
+
+	case "prefix":
+		if len(args) < 2 || !validPrefix(args[1]) {
+			s.ReplyT("group.prefix_usage")
+			return
+		}
+		settings.Prefix = args[1]
+		reply = s.T("group.prefix_set", args[1])
+
+	case "quota":
+		quota, err := strconv.Atoi(strings.Join(args[1:], ""))
+		if err != nil || quota < 0 {
+			s.ReplyT("group.quota_usage")
+			return
+		}
+		settings.StickerQuota = quota
+		reply = s.T("group.quota_set", quota)
+
+	case "welcome":
+		text := strings.TrimSpace(regexp.MustCompile(`^\S+\s+\S+`).ReplaceAllString(s.MessageText, ""))
+		if text == "" {
+			s.ReplyT("group.welcome_usage")
+			return
+		}
+		if strings.EqualFold(text, "off") {
+			text = ""
+		}
+		settings.WelcomeText = text
+		reply = s.T("group.welcome_set")
+
+	default:
+		s.ReplyT("common.invalid_command")
+		return
+	}
+
+	if err := storage.SaveGroupSettings(settings); err != nil {
+		fmt.Println("Error saving group settings:", err)
+		s.ReplyT("group.save_failed")
+		return
+	}
+
+	s.Reply(reply)
+}
+
+func groupAllowHandler(s *state.MessageState, allow bool, args []string) {
+	if s.UserRole != "OWNER" {
+		s.ReplyT("group.owner_only")
+		return
+	}
+
+	groupJID := s.ChatJID
+	if len(args) > 0 {
+		jid, err := waTypes.ParseJID(args[0])
+		if err != nil || jid.Server != waTypes.GroupServer {
+			s.ReplyT("group.invalid_jid")
+			return
+		}
+		groupJID = jid
+	} else if !s.IsFromGroup {
+		s.ReplyT("group.allow_usage")
+		return
+	}
+
+	settings, err := storage.GetGroupSettings(groupJID.String())
+	if err != nil {
+		fmt.Println("Error getting group settings:", err)
+		s.ReplyT("group.load_failed")
+		return
+	}
+
+	settings.Allowed = allow
+	if err := storage.SaveGroupSettings(settings); err != nil {
+		fmt.Println("Error saving group settings:", err)
+		s.ReplyT("group.save_failed")
+		return
+	}
+
+	if allow {
+		s.ReplyT("group.allowed", groupJID.String())
+	} else {
+		s.ReplyT("group.denied", groupJID.String())
+	}
+}
+
+func formatGroupSettings(s *state.MessageState, settings storage.GroupSettings) string {
+	onOff := map[bool]string{true: s.T("group.on"), false: s.T("group.off")}
+
+	disabled := "-"
+	if len(settings.DisabledCommands) > 0 {
+		disabled = "!" + strings.Join(settings.DisabledCommands, ", !")
+	}
+	language := settings.Language
+	if language == "" {
+		language = s.T("group.default")
+	}
+	quota := s.T("group.unlimited")
+	if settings.StickerQuota > 0 {
+		quota = s.T("group.quota_per_day", settings.StickerQuota)
+	}
+	welcome := settings.WelcomeText
+	if welcome == "" {
+		welcome = "-"
+	}
+
+	return s.T(
+		"group.settings",
+		onOff[utils.IsAllowedGroup(s.ChatJID)],
+		onOff[settings.Enabled], disabled, language, settings.Prefix, quota, welcome,
+	)
+}
+
+// SendWelcome greets participants who joined an allowed group that has a
+// welcome text configured.
+func SendWelcome(client *whatsmeow.Client, evt *events.GroupInfo) {
+	if len(evt.Join) == 0 || !utils.IsAllowedGroup(evt.JID) {
+		return
+	}
+
+	settings, err := storage.GetGroupSettings(evt.JID.String())
+	if err != nil || !settings.Enabled || settings.WelcomeText == "" {
+		return
+	}
+
+	groupName := ""
+	if entry, exists := utils.GroupCache.Get(client, evt.JID); exists {
+		groupName = entry.Name
+	}
+
+	for _, jid := range evt.Join {
+		jid = jid.ToNonAD()
+		text := strings.ReplaceAll(settings.WelcomeText, "{user}", "@"+jid.User)
+		text = strings.ReplaceAll(text, "{group}", groupName)
+
+		_, err := client.SendMessage(context.Background(), evt.JID, &waProto.Message{
+			ExtendedTextMessage: &waProto.ExtendedTextMessage{
+				Text: proto.String(text),
+				ContextInfo: &waProto.ContextInfo{
+					MentionedJID: []string{jid.String()},
+				},
+			},
+		})
+		if err != nil {
+			fmt.Println("Failed to send welcome message:", err)
+		}
+	}
+}
+
+// validPrefix accepts up to 3 symbols. Letters and digits are refused so that
+// ordinary chat is never parsed as a command.
+func validPrefix(prefix string) bool {
+	runes := []rune(prefix)
+	if len(runes) == 0 || len(runes) > 3 {
+		return false
+	}
+	for _, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func removeString(slice []string, item string) []string {
+	var result []string
+	for _, v := range slice {
+		if v != item {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package commonHandlers
+
+import (
+	"slices"
+	"testing"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state/statetest"
+	"wa-bot/storage"
+	"wa-bot/utils"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// cacheGroup puts a group in the group cache so that admin checks do not
+// need a client. Each test uses its own group, since group settings stay
+// cached in memory between tests.
+func cacheGroup(t *testing.T, group waTypes.JID, participants ...waTypes.GroupParticipant) {
+	t.Helper()
+	utils.GroupCache.Set(&waTypes.GroupInfo{JID: group, Participants: participants})
+	t.Cleanup(func() { utils.GroupCache.Remove(group) })
+}
+
+func TestGroupSettingsSubcommands(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	group := waTypes.NewJID("120363000000000011", waTypes.GroupServer)
+	cacheGroup(t, group, waTypes.GroupParticipant{JID: testUser, IsAdmin: true})
+
+	steps := []struct {
+		text string
+		want string
+	}{
+		{"!group disable", i18n.T("en", "group.disabled")},
+		{"!check", ""},
+		{"!group enable", i18n.T("en", "group.enabled")},
+		{"!check", i18n.T("en", "common.hello")},
+		{"!group disable !check", i18n.T("en", "group.cmd_disabled", "check")},
+		{"!check", i18n.T("en", "common.command_disabled")},
+		{"!group disable nope", i18n.T("en", "group.unknown_cmd", "nope")},
+		{"!group disable group", i18n.T("en", "group.unknown_cmd", "group")},
+		{"!group enable check", i18n.T("en", "group.cmd_enabled", "check")},
+		{"!check", i18n.T("en", "common.hello")},
+		{"!group lang xx", i18n.T("en", "group.lang_usage", "en/id")},
+		{"!group lang id", i18n.T("id", "group.lang_set", "id")},
+		{"!group quota -1", i18n.T("en", "group.quota_usage")},
+		{"!group quota 5", i18n.T("en", "group.quota_set", 5)},
+		{"!group welcome", i18n.T("en", "group.welcome_usage")},
+		{"!group welcome Hi {user}, welcome to {group}", i18n.T("en", "group.welcome_set")},
+		{"!group prefix a", i18n.T("en", "group.prefix_usage")},
+		{"!group prefix 1", i18n.T("en", "group.prefix_usage")},
+		{"!group prefix .x", i18n.T("en", "group.prefix_usage")},
+		{"!group prefix ....", i18n.T("en", "group.prefix_usage")},
+		{"!group prefix .", i18n.T("en", "group.prefix_set", ".")},
+		{"!check", ""},
+		{".check", i18n.T("en", "common.hello")},
+		{".group nope", i18n.T("en", "common.invalid_command")},
+	}
+
+	for _, step := range steps {
+		before := len(messenger.Texts())
+		commands.Dispatch(newTestState(messenger, group, testUser, step.text, nil))
+
+		texts := messenger.Texts()
+		if step.want == "" {
+			if len(texts) != before {
+				t.Fatalf("%s: replied %q", step.text, texts[before:])
+			}
+			continue
+		}
+		if len(texts) != before+1 || texts[before] != step.want {
+			t.Fatalf("%s: replied %q, want %q", step.text, texts[before:], step.want)
+		}
+	}
+
+	settings, err := storage.GetGroupSettings(group.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.Enabled || len(settings.DisabledCommands) != 0 || settings.Language != "id" ||
+		settings.StickerQuota != 5 || settings.Prefix != "." || settings.WelcomeText != "Hi {user}, welcome to {group}" {
+		t.Errorf("settings %+v", settings)
+	}
+}
+
+func TestGroupSettingsNeedGroupAdmin(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	group := waTypes.NewJID("120363000000000012", waTypes.GroupServer)
+	cacheGroup(t, group, waTypes.GroupParticipant{JID: testUser})
+
+	s := newTestState(messenger, group, testUser, "!group disable", nil)
+	commands.Dispatch(s)
+	if got := lastText(t, messenger); got != i18n.T("en", "group.admin_only") {
+		t.Fatalf("replied %q", got)
+	}
+
+	// Anyone may read the settings, and the owner may change them without
+	// being a group admin.
+	s = newTestState(messenger, group, testUser, "!group settings", nil)
+	commands.Dispatch(s)
+	if got := lastText(t, messenger); got == i18n.T("en", "group.admin_only") {
+		t.Fatal("settings are admin only")
+	}
+
+	s = newTestState(messenger, group, testUser, "!group quota 3", nil)
+	s.UserRole = "OWNER"
+	commands.Dispatch(s)
+	if got := lastText(t, messenger); got != i18n.T("en", "group.quota_set", 3) {
+		t.Fatalf("owner got %q", got)
+	}
+
+	s = newTestState(messenger, testUser, testUser, "!group quota 3", nil)
+	commands.Dispatch(s)
+	if got := lastText(t, messenger); got != i18n.T("en", "common.group_only") {
+		t.Fatalf("DM got %q", got)
+	}
+}
+
+func TestGroupAllowOwnerOnly(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	group := waTypes.NewJID("120363000000000013", waTypes.GroupServer)
+	other := waTypes.NewJID("120363000000000014", waTypes.GroupServer)
+	cacheGroup(t, group, waTypes.GroupParticipant{JID: testUser, IsAdmin: true})
+
+	steps := []struct {
+		chat waTypes.JID
+		role string
+		text string
+		want string
+	}{
+		{group, "COMMON", "!group allow", i18n.T("en", "group.owner_only")},
+		{group, "ADMIN", "!group allow", i18n.T("en", "group.owner_only")},
+		{group, "OWNER", "!group allow", i18n.T("en", "group.allowed", group.String())},
+		{testUser, "OWNER", "!group allow", i18n.T("en", "group.allow_usage")},
+		{testUser, "OWNER", "!group allow 6281200000001@s.whatsapp.net", i18n.T("en", "group.invalid_jid")},
+		{testUser, "OWNER", "!group allow " + other.String(), i18n.T("en", "group.allowed", other.String())},
+		{testUser, "OWNER", "!group deny " + group.String(), i18n.T("en", "group.denied", group.String())},
+	}
+
+	for _, step := range steps {
+		s := newTestState(messenger, step.chat, testUser, step.text, nil)
+		s.UserRole = step.role
+		commands.Dispatch(s)
+		if got := lastText(t, messenger); got != step.want {
+			t.Fatalf("%s %s: replied %q, want %q", step.role, step.text, got, step.want)
+		}
+	}
+
+	var allowed []string
+	for _, jid := range []waTypes.JID{group, other} {
+		if utils.IsAllowedGroup(jid) {
+			allowed = append(allowed, jid.User)
+		}
+	}
+	if want := []string{other.User}; !slices.Equal(allowed, want) {
+		t.Errorf("allowed groups %v, want %v", allowed, want)
+	}
+}
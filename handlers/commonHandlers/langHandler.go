@@ -0,0 +1,46 @@
+package commonHandlers
+
+import (
+	"fmt"
+	"strings"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state"
+	"wa-bot/storage"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "lang",
+		Args:     `(\s+\S+)?`,
+		Category: "help.cat_general",
+		Usage:    []string{"help.lang"},
+		Handler:  LangHandler,
+	})
+}
+
+func LangHandler(s *state.MessageState) {
+	languages := strings.Join(i18n.Languages(), "/")
+
+	args := strings.Fields(s.MessageText)[1:]
+	if len(args) == 0 {
+		s.ReplyT("lang.current", s.Language, languages)
+		return
+	}
+
+	lang := strings.ToLower(args[0])
+	if !i18n.IsSupported(lang) {
+		s.ReplyT("lang.usage", languages)
+		return
+	}
+
+	if err := storage.SetUserLanguage(s.SenderJID.String(), lang); err != nil {
+		fmt.Println("Error saving user language:", err)
+		s.ReplyT("lang.failed")
+		return
+	}
+
+	s.Language = lang
+	s.ReplyT("lang.set", lang)
+}
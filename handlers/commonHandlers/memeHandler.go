@@ -0,0 +1,74 @@
+package commonHandlers
+
+import (
+	"errors"
+	"strings"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "smeme",
+		Args:     `(\s+\S+)+`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage: []string{
+			"help.smeme",
+			"help.smeme_bottom",
+		},
+		Handler: SmemeHandler,
+	})
+}
+
+func SmemeHandler(s *state.MessageState) {
+	top, bottom, err := parseMemeCaption(s.MessageText)
+	if err != nil {
+		s.ReplyErr(err)
+		return
+	}
+	if top == "" && bottom == "" {
+		s.ReplyT("sticker.smeme_usage")
+		return
+	}
+
+	makeSticker(s, func() (string, error) {
+		path, err := utils.RenderCaptionPng(top, bottom)
+		if errors.Is(err, utils.ErrorNoRenderableText) {
+			return path, i18n.Errorf("sticker.no_renderable_text")
+		}
+		return path, err
+	})
+}
+
+// parseMemeCaption takes the words of the message that are not sticker
+// options or links and splits them at "|" into the top and bottom caption.
+func parseMemeCaption(messageText string) (string, string, error) {
+	_, rest, err := parseStickerOptions(messageText)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(rest) > 0 {
+		rest = rest[1:] // the command
+	}
+
+	var words []string
+	for _, word := range rest {
+		lower := strings.ToLower(word)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			words = append(words, word)
+		}
+	}
+
+	parts := strings.SplitN(strings.Join(words, " "), "|", 2)
+	top := strings.TrimSpace(parts[0])
+	bottom := ""
+	if len(parts) == 2 {
+		bottom = strings.TrimSpace(parts[1])
+	}
+	return top, bottom, nil
+}
@@ -0,0 +1,55 @@
+package commonHandlers
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"wa-bot/i18n"
+)
+
+func TestParseMemeCaption(t *testing.T) {
+	tests := []struct {
+		text       string
+		top        string
+		bottom     string
+		wantErrKey string
+	}{
+		{"!smeme hello|world", "hello", "world", ""},
+		{"!smeme |bottom only", "", "bottom only", ""},
+		{"!smeme nocrop top text | bottom text fps=10", "top text", "bottom text", ""},
+		{`!smeme pack="My Pack" author=me when the | build passes`, "when the", "build passes", ""},
+		{"!smeme https://example.com/cat.png page=2 bg=remove cat|dog", "cat", "dog", ""},
+		{"!smeme Nocrop FLIP hi", "hi", "", ""},
+		{"!smeme", "", "", ""},
+		{"!smeme fps=100 hello", "", "", "sticker.invalid_fps"},
+	}
+
+	for _, tt := range tests {
+		top, bottom, err := parseMemeCaption(tt.text)
+		if tt.wantErrKey != "" {
+			var catalogErr *i18n.Error
+			if !errors.As(err, &catalogErr) || catalogErr.Key != tt.wantErrKey {
+				t.Errorf("%q: got error %v, want %s", tt.text, err, tt.wantErrKey)
+			}
+			continue
+		}
+		if err != nil || top != tt.top || bottom != tt.bottom {
+			t.Errorf("%q: got %q|%q %v, want %q|%q", tt.text, top, bottom, err, tt.top, tt.bottom)
+		}
+	}
+}
+
+func TestParseStickerOptionsRest(t *testing.T) {
+	opt, rest, err := parseStickerOptions(`!sticker https://example.com/a.gif nocrop pack="Two words" author=me start=00:01 page=all extra`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opt.NoCrop || opt.StartTime != "00:01" || opt.Metadata.PackName != "Two words" || opt.Metadata.Publisher != "me" {
+		t.Errorf("options %+v", opt)
+	}
+	want := []string{"!sticker", "https://example.com/a.gif", "extra"}
+	if !slices.Equal(rest, want) {
+		t.Errorf("rest %q, want %q", rest, want)
+	}
+}
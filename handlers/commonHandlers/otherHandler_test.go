@@ -0,0 +1,92 @@
+package commonHandlers
+
+import (
+	"image/color"
+	"testing"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state/statetest"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+func TestCancelOnlyOwnJobInGroup(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	alice := testUser
+	bob := waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+
+	// Alice has a long job running in the group.
+	aliceCanceled := false
+	aliceJob := newTestState(messenger, testGroup, alice, "!sticker", nil)
+	aliceJob.AddUserToState("processing", func() { aliceCanceled = true })
+	t.Cleanup(aliceJob.ClearUserState)
+
+	steps := []struct {
+		sender waTypes.JID
+		text   string
+		want   string
+	}{
+		{bob, "!check", i18n.T("en", "common.hello")},
+		{bob, "!cancel", i18n.T("en", "cancel.none")},
+		{alice, "!check", i18n.T("en", "common.busy")},
+		{alice, "!cancel", i18n.T("en", "cancel.success")},
+		{alice, "!check", i18n.T("en", "common.hello")},
+	}
+
+	for _, step := range steps {
+		s := newTestState(messenger, testGroup, step.sender, step.text, nil)
+		commands.Dispatch(s)
+		if got := lastText(t, messenger); got != step.want {
+			t.Fatalf("%s %s: replied %q, want %q", step.sender.User, step.text, got, step.want)
+		}
+		if step.sender == bob && aliceCanceled {
+			t.Fatalf("bob's %s canceled alice's job", step.text)
+		}
+	}
+
+	if !aliceCanceled {
+		t.Fatal("alice's job was not canceled")
+	}
+}
+
+func TestConcurrentJobsInGroup(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	bob := waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+
+	// Alice's job is still running while Bob asks for a sticker.
+	aliceJob := newTestState(messenger, testGroup, testUser, "!sticker", nil)
+	aliceJob.AddUserToState("processing", func() {})
+	t.Cleanup(aliceJob.ClearUserState)
+
+	s := newTestState(messenger, testGroup, bob, "!sticker", imageMessage(messenger, "!sticker", testPng(t, color.White)))
+	commands.Dispatch(s)
+	waitForJob(t, s)
+
+	stickers := messenger.Stickers()
+	if len(stickers) != 1 {
+		t.Fatalf("bob got no sticker, replies %q", messenger.Texts())
+	}
+	if got := stickers[0].GetContextInfo().GetParticipant(); got != bob.String() {
+		t.Errorf("sticker quotes %q, want bob", got)
+	}
+	if aliceJob.CheckUserState() != "processing" {
+		t.Fatal("bob's job ended alice's")
+	}
+}
+
+func TestHelpKeysInCatalog(t *testing.T) {
+	for _, cmd := range commands.All() {
+		keys := append([]string{cmd.Category}, cmd.Usage...)
+		if cmd.Details != "" {
+			keys = append(keys, cmd.Details)
+		}
+		for _, key := range keys {
+			if i18n.T("en", key) == key {
+				t.Errorf("%s: %q is not in the catalog", cmd.Name, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package commonHandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/storage"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "pack",
+		Args:     `(\s+\S+)+`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage: []string{
+			"help.pack_create",
+			"help.pack_add",
+			"help.pack_list",
+			"help.pack_export",
+		},
+		Handler: PackHandler,
+	})
+}
+
+const maxPacksPerUser = 10
+
+func PackHandler(s *state.MessageState) {
+	args := strings.Fields(s.MessageText)[1:]
+	name := strings.TrimSpace(regexp.MustCompile(`^\S+\s+\S+`).ReplaceAllString(s.MessageText, ""))
+	owner := s.SenderJID.String()
+
+	switch strings.ToLower(args[0]) {
+	case "create":
+		packCreateHandler(s, owner, name)
+	case "add":
+		packAddHandler(s, owner)
+	case "list":
+		packListHandler(s, owner)
+	case "export":
+		packExportHandler(s, owner, name)
+	default:
+		s.ReplyT("pack.usage")
+	}
+}
+
+func packCreateHandler(s *state.MessageState, owner, name string) {
+	if name == "" {
+		s.ReplyT("pack.usage")
+		return
+	}
+	if len([]rune(name)) > maxMetadataLen {
+		s.ReplyT("sticker.prefs_too_long", maxMetadataLen)
+		return
+	}
+
+	if pack, err := storage.SelectStickerPack(owner, name); err == nil {
+		s.ReplyT("pack.selected", pack.Name, pack.Count, utils.MaxPackStickers)
+		return
+	} else if !errors.Is(err, storage.ErrorPackNotFound) {
+		fmt.Println("Error selecting sticker pack:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+
+	packs, err := storage.ListStickerPacks(owner)
+	if err != nil {
+		fmt.Println("Error listing sticker packs:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+	if len(packs) >= maxPacksPerUser {
+		s.ReplyT("pack.too_many", maxPacksPerUser)
+		return
+	}
+
+	pack, err := storage.CreateStickerPack(owner, name)
+	if err != nil {
+		fmt.Println("Error creating sticker pack:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+	s.ReplyT("pack.created", pack.Name)
+}
+
+func packAddHandler(s *state.MessageState, owner string) {
+	pack, err := storage.GetCurrentStickerPack(owner)
+	if errors.Is(err, storage.ErrorPackNotFound) {
+		s.ReplyT("pack.none")
+		return
+	} else if err != nil {
+		fmt.Println("Error getting sticker pack:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+	if pack.Count >= utils.MaxPackStickers {
+		s.ReplyT("pack.full", pack.Name, utils.MaxPackStickers)
+		return
+	}
+
+	var data []byte
+	if quoted := s.QuotedMessage(); quoted.GetStickerMessage() != nil {
+		data, _, err = s.DownloadMessageMedia(quoted)
+		if err != nil {
+			fmt.Println("Error downloading sticker:", err)
+			s.ReplyT("pack.failed")
+			return
+		}
+	} else if recent, ok := recentStickers.Get(owner); ok {
+		data = recent
+	} else {
+		s.ReplyT("pack.no_sticker")
+		return
+	}
+
+	// Stickers are decoded again for the tray icon on export, so anything
+	// the decoder rejects is kept out of the pack.
+	if _, _, err := utils.DecodeWebpFrames(data); err != nil {
+		fmt.Println("Error decoding sticker:", err)
+		s.ReplyT("pack.invalid_sticker")
+		return
+	}
+
+	err = storage.AddPackSticker(pack.ID, data)
+	if errors.Is(err, storage.ErrorStickerInPack) {
+		s.ReplyT("pack.duplicate", pack.Name)
+		return
+	} else if err != nil {
+		fmt.Println("Error adding sticker to pack:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+	s.ReplyT("pack.added", pack.Name, pack.Count+1, utils.MaxPackStickers)
+}
+
+func packListHandler(s *state.MessageState, owner string) {
+	packs, err := storage.ListStickerPacks(owner)
+	if err != nil {
+		fmt.Println("Error listing sticker packs:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+	if len(packs) == 0 {
+		s.ReplyT("pack.none")
+		return
+	}
+
+	var lines []string
+	for i, pack := range packs {
+		line := s.T("pack.list_item", pack.Name, pack.Count, utils.MaxPackStickers)
+		if i == 0 {
+			line += " " + s.T("pack.current")
+		}
+		lines = append(lines, line)
+	}
+	s.Reply(s.T("pack.list_title") + "\n" + strings.Join(lines, "\n"))
+}
+
+func packExportHandler(s *state.MessageState, owner, name string) {
+	var pack storage.StickerPack
+	var err error
+	if name == "" {
+		pack, err = storage.GetCurrentStickerPack(owner)
+	} else {
+		pack, err = storage.GetStickerPack(owner, name)
+	}
+	if errors.Is(err, storage.ErrorPackNotFound) {
+		if name == "" {
+			s.ReplyT("pack.none")
+		} else {
+			s.ReplyT("pack.not_found", name)
+		}
+		return
+	} else if err != nil {
+		fmt.Println("Error getting sticker pack:", err)
+		s.ReplyT("pack.failed")
+		return
+	}
+
+	if pack.Count < utils.MinPackStickers {
+		s.ReplyT("pack.too_few", pack.Name, pack.Count, utils.MinPackStickers)
+		return
+	}
+
+	s.ReplyT("common.loading")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddUserToState("processing", cancel)
+
+	go func() {
+		defer s.ClearUserState()
+		defer cancel()
+
+		stickers, err := storage.GetPackStickers(pack.ID)
+		if err != nil {
+			fmt.Println("Error reading pack stickers:", err)
+			s.ReplyT("pack.failed")
+			return
+		}
+
+		author := firstNonEmpty(stickerMetadata(s, utils.StickerMetadata{}).Publisher, s.Inbound.PushName)
+		zipData, err := utils.BuildWastickers(pack.Name, author, stickers)
+		if err != nil {
+			fmt.Println("Error building sticker pack:", err)
+			s.ReplyT("pack.failed")
+			return
+		}
+
+		uploaded, err := s.UploadToWhatsapp(ctx, zipData, "document")
+		if err != nil {
+			utils.LogNoCancelErr(ctx, err, "Error uploading sticker pack:")
+			s.ReplyNoCancelError(ctx, err, s.T("pack.failed"))
+			return
+		}
+
+		// Sticker apps pick .wastickers files up by extension, not by type.
+		err = s.SendDocumentMessage(ctx, uploaded, packFileName(pack.Name), "application/octet-stream")
+		if err != nil {
+			utils.LogNoCancelErr(ctx, err, "Error sending sticker pack:")
+			s.ReplyNoCancelError(ctx, err, s.T("pack.failed"))
+		}
+	}()
+}
+
+var unsafeFileNameRe = regexp.MustCompile(`[^\p{L}\p{N} ._-]+`)
+
+func packFileName(name string) string {
+	name = strings.TrimSpace(unsafeFileNameRe.ReplaceAllString(name, ""))
+	if name == "" {
+		name = "stickers"
+	}
+	return name + ".wastickers"
+}
+
+// recentStickers keeps the last sticker the bot made for each user for a
+// while, so "!pack add" works right after making one without a reply.
+var recentStickers = &recentStickerStore{data: make(map[string]recentSticker)}
+
+const recentStickerTTL = 10 * time.Minute
+
+type recentStickerStore struct {
+	sync.Mutex
+	data map[string]recentSticker
+}
+
+type recentSticker struct {
+	Data   []byte
+	SentAt time.Time
+}
+
+func (r *recentStickerStore) Put(jid string, data []byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	for key, sticker := range r.data {
+		if time.Since(sticker.SentAt) > recentStickerTTL {
+			delete(r.data, key)
+		}
+	}
+	r.data[jid] = recentSticker{Data: data, SentAt: time.Now()}
+}
+
+func (r *recentStickerStore) Get(jid string) ([]byte, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	sticker, exists := r.data[jid]
+	if !exists || time.Since(sticker.SentAt) > recentStickerTTL {
+		return nil, false
+	}
+	return sticker.Data, true
+}
@@ -0,0 +1,128 @@
+package commonHandlers
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/utils"
+
+	"go.mau.fi/whatsmeow"
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "qc",
+		Args:     `(\s+[\s\S]+)?`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage: []string{
+			"help.qc",
+			"help.qc_text",
+		},
+		Handler: QCHandler,
+	})
+}
+
+func QCHandler(s *state.MessageState) {
+	text := strings.TrimSpace(regexp.MustCompile(`^\S+`).ReplaceAllString(s.MessageText, ""))
+	sender := s.SenderJID
+	name := s.Inbound.PushName
+
+	if quoted := strings.TrimSpace(state.ExtractText(s.QuotedMessage())); quoted != "" && text == "" {
+		text = quoted
+		sender = s.Inbound.QuotedSender
+		if sender.IsEmpty() && !s.IsFromGroup {
+			sender = s.ChatJID
+		}
+		name = ""
+		if sender == s.SenderJID {
+			name = s.Inbound.PushName
+		}
+	}
+
+	if text == "" {
+		s.ReplyT("sticker.qc_usage")
+		return
+	}
+	if name == "" {
+		name = contactName(s.Messenger, sender)
+	}
+
+	if !checkStickerQuota(s) {
+		return
+	}
+	s.ReplyT("common.loading")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddUserToState("processing", cancel)
+
+	go func() {
+		defer s.ClearUserState()
+		defer cancel()
+
+		pngPath, err := utils.RenderQuotePng(utils.QuoteBubble{
+			Name:   name,
+			Text:   text,
+			Seed:   sender.String(),
+			Avatar: profilePicture(ctx, s.Messenger, sender),
+		})
+		defer os.Remove(pngPath)
+		if errors.Is(err, utils.ErrorNoRenderableText) {
+			s.ReplyT("sticker.no_renderable_text")
+			return
+		} else if err != nil {
+			utils.LogNoCancelErr(ctx, err, "error:")
+			s.ReplyT("sticker.convert_failed")
+			return
+		}
+
+		err = sendMediaAsSticker(ctx, s, pngPath, nil, &utils.StickerOptions{NoCrop: true})
+		if err == nil && !utils.IsCanceledGoroutine(ctx) {
+			countStickerUsage(s)
+		}
+		if err != nil {
+			replyStickerError(ctx, s, err)
+		}
+	}()
+}
+
+// contactName returns the name the bot knows jid by, or the phone number if
+// the contact has never been seen.
+func contactName(messenger state.Messenger, jid waTypes.JID) string {
+	if contact, err := messenger.GetContact(jid); err == nil {
+		if name := firstNonEmpty(contact.PushName, contact.FullName, contact.BusinessName); name != "" {
+			return name
+		}
+	}
+	if jid.User == "" {
+		return "?"
+	}
+	return "+" + jid.User
+}
+
+// profilePicture fetches the preview profile picture of jid. It returns nil
+// when the picture is hidden or cannot be fetched, so a placeholder is drawn.
+func profilePicture(ctx context.Context, messenger state.Messenger, jid waTypes.JID) image.Image {
+	info, err := messenger.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{Preview: true})
+	if err != nil || info == nil || info.URL == "" {
+		return nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	img, err := utils.FetchImage(fetchCtx, info.URL)
+	if err != nil {
+		utils.LogNoCancelErr(ctx, err, "failed to fetch profile picture:")
+		return nil
+	}
+	return img
+}
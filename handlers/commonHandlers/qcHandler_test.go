@@ -0,0 +1,91 @@
+package commonHandlers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"wa-bot/state/statetest"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestContactName(t *testing.T) {
+	messenger := &statetest.FakeMessenger{Contacts: map[string]waTypes.ContactInfo{
+		"6281200000001@s.whatsapp.net": {Found: true, PushName: "Alice", FullName: "Alice A"},
+		"6281200000002@s.whatsapp.net": {Found: true, FullName: "Bob B"},
+		"6281200000003@s.whatsapp.net": {Found: true, BusinessName: "Carol's Shop"},
+	}}
+
+	tests := []struct {
+		jid  waTypes.JID
+		want string
+	}{
+		{waTypes.NewJID("6281200000001", waTypes.DefaultUserServer), "Alice"},
+		{waTypes.NewJID("6281200000002", waTypes.DefaultUserServer), "Bob B"},
+		{waTypes.NewJID("6281200000003", waTypes.DefaultUserServer), "Carol's Shop"},
+		{waTypes.NewJID("6281200000004", waTypes.DefaultUserServer), "+6281200000004"},
+		{waTypes.EmptyJID, "?"},
+	}
+
+	for _, tt := range tests {
+		if got := contactName(messenger, tt.jid); got != tt.want {
+			t.Errorf("contactName(%v) = %q, want %q", tt.jid, got, tt.want)
+		}
+	}
+}
+
+func TestProfilePictureFallback(t *testing.T) {
+	hidden := waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+	messenger := &statetest.FakeMessenger{ProfilePictures: map[string]*waTypes.ProfilePictureInfo{
+		hidden.String(): {ID: "1"},
+	}}
+
+	for _, jid := range []waTypes.JID{testUser, hidden} {
+		if avatar := profilePicture(context.Background(), messenger, jid); avatar != nil {
+			t.Errorf("%v: got an avatar without a picture URL", jid)
+		}
+	}
+}
+
+func TestQCHandlerWithoutPushNameOrAvatar(t *testing.T) {
+	setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	bob := waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+
+	tests := []struct {
+		name       string
+		text       string
+		quote      bool
+		wantLookup waTypes.JID
+	}{
+		{"own text", "!qc hello", false, testUser},
+		{"quoted text", "!qc", true, bob},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger.Lookups = nil
+			before := len(messenger.Stickers())
+
+			s := newTestState(messenger, testGroup, testUser, tt.text, nil)
+			if tt.quote {
+				s.Inbound.Quoted = &waProto.Message{Conversation: proto.String("hi from bob")}
+				s.Inbound.QuotedSender = bob
+			}
+			QCHandler(s)
+			waitForJob(t, s)
+
+			if got := len(messenger.Stickers()) - before; got != 1 {
+				t.Fatalf("sent %d stickers, replies %q", got, messenger.Texts())
+			}
+			// The name comes from the contact store and the avatar lookup
+			// finds nothing, so a placeholder is drawn.
+			if want := []waTypes.JID{tt.wantLookup, tt.wantLookup}; !slices.Equal(messenger.Lookups, want) {
+				t.Errorf("looked up %v, want %v", messenger.Lookups, want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package commonHandlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -10,16 +12,45 @@ import (
 	"strings"
 	"time"
 
+	"wa-bot/commands"
+	"wa-bot/i18n"
 	"wa-bot/state"
+	"wa-bot/storage"
 	"wa-bot/utils"
 )
 
+// stickerRoles is every role: the sticker commands are open to anyone the bot
+// answers, and acl.json can still narrow them per command.
+var stickerRoles = utils.Roles
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "sticker",
+		Args:     `(\s+\S+)*`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage: []string{
+			"help.sticker_url",
+			"help.sticker_media",
+			"help.sticker_urls",
+			"help.sticker_album",
+		},
+		Details: "help.sticker_details",
+		Handler: StickerHandler,
+	})
+}
+
 func StickerHandler(s *state.MessageState) {
-	if s.UserRole != "OWNER" && s.UserRole != "COMMON" {
-		s.Reply("Invalid Command")
+	makeSticker(s, nil)
+}
+
+// makeSticker runs the !sticker pipeline in the background. A non-nil
+// overlay renders a PNG that is drawn over every frame of the sticker.
+func makeSticker(s *state.MessageState, overlay func() (string, error)) {
+	if !checkStickerQuota(s) {
 		return
 	}
-	s.Reply("⏳ Loading...")
+	s.ReplyT("common.loading")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	s.AddUserToState("processing", cancel)
@@ -28,55 +59,198 @@ func StickerHandler(s *state.MessageState) {
 		defer s.ClearUserState()
 		defer cancel()
 
-		opt, err := parseStickerOptions(s.MessageText)
+		opt, _, err := parseStickerOptions(s.MessageText)
 		if err != nil {
-			s.Reply(err.Error())
+			s.ReplyErr(err)
 			return
 		}
 
 		if opt.StartTime != "" && opt.EndTime != "" {
 			if err := validateTimeRange(opt); err != nil {
-				s.Reply(err.Error())
+				s.ReplyErr(err)
+				return
+			}
+		}
+
+		if overlay != nil {
+			opt.Overlay, err = overlay()
+			defer os.Remove(opt.Overlay)
+			if err != nil {
+				s.ReplyErr(err)
 				return
 			}
 		}
 
-		mediaPath, isAnimated, err := getMedia(ctx, s, s.MessageText)
-		defer os.Remove(mediaPath)
+		sources, err := getMediaSources(ctx, s, s.MessageText)
 		if err != nil {
 			handleMediaError(ctx, s, err)
 			return
 		}
-		opt.IsAnimated = isAnimated
 
-		if utils.IsCanceledGoroutine(ctx) {
-			return
+		if len(sources) == 1 {
+			makeSingleSticker(ctx, s, sources[0], opt)
+		} else {
+			makeStickerBatch(ctx, s, sources, opt)
 		}
+	}()
+}
+
+func makeSingleSticker(ctx context.Context, s *state.MessageState, source mediaSource, opt *utils.StickerOptions) {
+	opt.IsAnimated = source.IsAnimated
+	if sendCachedSticker(ctx, s, source.Hash, opt) {
+		countStickerUsage(s)
+		return
+	}
+
+	mediaPath, isAnimated, err := source.Fetch(ctx)
+	defer os.Remove(mediaPath)
+	if err != nil {
+		handleMediaError(ctx, s, err)
+		return
+	}
+	opt.IsAnimated = isAnimated
 
-		if !validateVideoDuration(ctx, s, mediaPath, opt) {
+	if utils.IsCanceledGoroutine(ctx) {
+		return
+	}
+
+	if !validateVideoDuration(ctx, s, mediaPath, opt) {
+		return
+	}
+
+	err = sendMediaAsSticker(ctx, s, mediaPath, source.Hash, opt)
+	if err == nil && !utils.IsCanceledGoroutine(ctx) {
+		countStickerUsage(s)
+	}
+	if err != nil {
+		replyStickerError(ctx, s, err)
+	}
+}
+
+// maxBatchStickers caps the stickers made from one message.
+const maxBatchStickers = 10
+
+// makeStickerBatch makes one sticker per source, editing a single progress
+// message as it goes. Failed items are skipped and counted in the summary.
+func makeStickerBatch(ctx context.Context, s *state.MessageState, sources []mediaSource, opt *utils.StickerOptions) {
+	total := len(sources)
+	limit := maxBatchStickers
+	if used, quota := stickerQuota(s); quota > 0 {
+		limit = min(limit, max(quota-used, 0))
+	}
+	if total > limit {
+		s.ReplyT("sticker.batch_capped", limit, total)
+		sources = sources[:limit]
+	}
+
+	progress := s.ReplyEditable(s.T("sticker.batch_progress", 0, len(sources)))
+	sent := 0
+	for i, source := range sources {
+		if utils.IsCanceledGoroutine(ctx) {
 			return
 		}
 
-		if err := sendMediaAsSticker(ctx, s, mediaPath, opt); err != nil {
-			if errors.Is(err, utils.ErrorNotUnder1MB) {
-				s.Reply(
-					"Failed to convert media under 1MB. Consider trying one of the following:\n" +
-						"- Lower the quality with: quality=<0-100>\n" +
-						"- Reduce the video duration: start=MM:SS end=MM:SS\n" +
-						"- Reduce the video FPS: fps=<1-60>",
-				)
-			} else {
-				utils.LogNoCancelErr(ctx, err, "error:")
-				s.ReplyNoCancelError(ctx, err, "Server error: failed to convert sticker")
+		itemOpt := *opt
+		itemOpt.IsAnimated = source.IsAnimated
+		var err error
+		if !sendCachedSticker(ctx, s, source.Hash, &itemOpt) {
+			var mediaPath string
+			mediaPath, itemOpt.IsAnimated, err = source.Fetch(ctx)
+			if err == nil {
+				err = sendMediaAsSticker(ctx, s, mediaPath, source.Hash, &itemOpt)
 			}
+			os.Remove(mediaPath)
 		}
-	}()
+
+		if err != nil {
+			utils.LogNoCancelErr(ctx, err, "error:")
+		} else if !utils.IsCanceledGoroutine(ctx) {
+			sent++
+			countStickerUsage(s)
+		}
+		progress(s.T("sticker.batch_progress", i+1, len(sources)))
+	}
+
+	if !utils.IsCanceledGoroutine(ctx) {
+		progress(s.T("sticker.batch_done", sent, len(sources)))
+	}
+}
+
+func replyStickerError(ctx context.Context, s *state.MessageState, err error) {
+	if errors.Is(err, utils.ErrorNotUnder1MB) {
+		s.ReplyT("sticker.not_under_1mb")
+	} else {
+		utils.LogNoCancelErr(ctx, err, "error:")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.convert_failed"))
+	}
+}
+
+// checkStickerQuota enforces the group's daily sticker quota per participant.
+func checkStickerQuota(s *state.MessageState) bool {
+	used, quota := stickerQuota(s)
+	if quota > 0 && used >= quota {
+		s.ReplyT("sticker.quota_reached", used, quota)
+		return false
+	}
+	return true
+}
+
+// stickerQuota returns how many stickers the sender made today in this group
+// and the group's daily quota, which is 0 when there is no limit.
+func stickerQuota(s *state.MessageState) (int, int) {
+	if !s.IsFromGroup || s.UserRole == "OWNER" {
+		return 0, 0
+	}
+
+	settings, err := storage.GetGroupSettings(s.ChatJID.String())
+	if err != nil || settings.StickerQuota <= 0 {
+		return 0, 0
+	}
+
+	used, err := storage.GetStickerUsage(s.ChatJID.String(), s.SenderJID.String(), time.Now().Format("2006-01-02"))
+	if err != nil {
+		fmt.Println("Error getting sticker usage:", err)
+		return 0, 0
+	}
+
+	return used, settings.StickerQuota
 }
 
-func parseStickerOptions(messageText string) (*utils.StickerOptions, error) {
+func countStickerUsage(s *state.MessageState) {
+	if !s.IsFromGroup {
+		return
+	}
+
+	_, err := storage.IncrementStickerUsage(s.ChatJID.String(), s.SenderJID.String(), time.Now().Format("2006-01-02"))
+	if err != nil {
+		fmt.Println("Error counting sticker usage:", err)
+	}
+}
+
+// metadataOptionRe matches pack= and author= options, whose values may be
+// quoted to include spaces.
+var metadataOptionRe = regexp.MustCompile(`\s(pack|author)=(?:"([^"]*)"|(\S+))`)
+
+// parseStickerOptions reads the sticker options out of the message and
+// returns the words that are not options, starting with the command itself,
+// so that commands such as !smeme can use the rest as text.
+func parseStickerOptions(messageText string) (*utils.StickerOptions, []string, error) {
 	opt := &utils.StickerOptions{}
+	var rest []string
 	var err error
-	opt.NoCrop = strings.Contains(strings.ToLower(messageText), " nocrop")
+
+	for _, match := range metadataOptionRe.FindAllStringSubmatch(messageText, -1) {
+		value := strings.TrimSpace(match[2] + match[3])
+		if len([]rune(value)) > maxMetadataLen {
+			return nil, nil, i18n.Errorf("sticker.prefs_too_long", maxMetadataLen)
+		}
+		if match[1] == "pack" {
+			opt.Metadata.PackName = value
+		} else {
+			opt.Metadata.Publisher = value
+		}
+	}
+	messageText = metadataOptionRe.ReplaceAllString(messageText, "")
 
 	parts := strings.Fields(messageText)
 	for _, part := range parts {
@@ -89,54 +263,161 @@ func parseStickerOptions(messageText string) (*utils.StickerOptions, error) {
 			fpsStr := strings.TrimPrefix(part, "fps=")
 			opt.FPS, err = strconv.Atoi(fpsStr)
 			if err != nil || opt.FPS < 1 || opt.FPS > 60 {
-				return nil, errors.New("FPS must be between 1 and 60")
+				return nil, nil, i18n.Errorf("sticker.invalid_fps")
 			}
 		case strings.HasPrefix(part, "quality="):
 			qualityStr := strings.TrimPrefix(part, "quality=")
 			opt.Quality, err = strconv.Atoi(qualityStr)
 			if err != nil || opt.Quality < 1 || opt.Quality > 100 {
-				return nil, errors.New("Quality must be between 1 and 100")
+				return nil, nil, i18n.Errorf("sticker.invalid_quality")
+			}
+		case strings.HasPrefix(part, "emoji="):
+			emojis := strings.Split(strings.TrimPrefix(part, "emoji="), ",")
+			if len(emojis) > 3 {
+				return nil, nil, i18n.Errorf("sticker.too_many_emojis")
+			}
+			for _, emoji := range emojis {
+				if emoji != "" {
+					opt.Metadata.Emojis = append(opt.Metadata.Emojis, emoji)
+				}
+			}
+		case strings.HasPrefix(part, "shape="):
+			opt.Shape = strings.ToLower(strings.TrimPrefix(part, "shape="))
+			if opt.Shape != "circle" && opt.Shape != "rounded" && opt.Shape != "square" {
+				return nil, nil, i18n.Errorf("sticker.invalid_shape")
+			}
+		case strings.HasPrefix(part, "border="):
+			border, convErr := utils.ParseColor(strings.TrimPrefix(part, "border="))
+			if convErr != nil {
+				return nil, nil, i18n.Errorf("sticker.invalid_color")
+			}
+			opt.Border = &border
+		case strings.HasPrefix(part, "rotate="):
+			opt.Rotate, err = strconv.Atoi(strings.TrimPrefix(part, "rotate="))
+			if err != nil || (opt.Rotate != 90 && opt.Rotate != 180 && opt.Rotate != 270) {
+				return nil, nil, i18n.Errorf("sticker.invalid_rotate")
+			}
+		case strings.HasPrefix(part, "speed="):
+			speedStr := strings.TrimSuffix(strings.ToLower(strings.TrimPrefix(part, "speed=")), "x")
+			opt.Speed, err = strconv.ParseFloat(speedStr, 64)
+			if err != nil || opt.Speed < 0.5 || opt.Speed > 4 {
+				return nil, nil, i18n.Errorf("sticker.invalid_speed")
 			}
+		case strings.HasPrefix(part, "bg="):
+			if err := parseBackgroundOption(strings.TrimPrefix(part, "bg="), opt); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasPrefix(part, "page="):
+			// Read by getUrlSources, which needs it per link.
+		case strings.EqualFold(part, "nocrop"):
+			opt.NoCrop = true
+		case strings.EqualFold(part, "flip"):
+			opt.Flip = true
+		case strings.EqualFold(part, "mirror"):
+			opt.Mirror = true
+		case strings.EqualFold(part, "grayscale"):
+			opt.Grayscale = true
+		case strings.EqualFold(part, "reverse"):
+			opt.Reverse = true
+		case strings.EqualFold(part, "boomerang"):
+			opt.Boomerang = true
 		case strings.HasPrefix(part, "direction="):
 			rawDirection := strings.TrimPrefix(part, "direction=")
 			dParts := strings.Split(rawDirection, "-")
 			side := dParts[0]
 			if side != "up" && side != "down" && side != "left" && side != "right" {
-				return nil, errors.New("Direction invalid. Use up, down, left, or right (with optional -0 to -50)")
+				return nil, nil, i18n.Errorf("sticker.invalid_dir")
 			}
 			if len(dParts) == 2 {
 				percentStr := dParts[1]
 				percent, convErr := strconv.Atoi(percentStr)
 				if convErr != nil || percent < 0 || percent > 50 {
-					return nil, errors.New("Direction offset must be between 0 and 50")
+					return nil, nil, i18n.Errorf("sticker.invalid_offset")
 				}
 			}
 			opt.Direction = rawDirection
+		default:
+			rest = append(rest, part)
+		}
+	}
+
+	return opt, rest, nil
+}
+
+// parseBackgroundOption parses the value of bg=remove[:<color>][:<similarity>].
+// Without a color, the background is detected from the corners.
+func parseBackgroundOption(value string, opt *utils.StickerOptions) error {
+	parts := strings.Split(value, ":")
+	if !strings.EqualFold(parts[0], "remove") || len(parts) > 3 {
+		return i18n.Errorf("sticker.invalid_bg")
+	}
+	opt.RemoveBackground = true
+
+	if len(parts) > 1 && parts[1] != "" && !strings.EqualFold(parts[1], "auto") {
+		background, err := utils.ParseColor(parts[1])
+		if err != nil {
+			return i18n.Errorf("sticker.invalid_color")
 		}
+		opt.BackgroundColor = &background
 	}
 
-	return opt, nil
+	if len(parts) > 2 {
+		similarity, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil || similarity < 0.01 || similarity > 1 {
+			return i18n.Errorf("sticker.invalid_similarity")
+		}
+		opt.BackgroundSimilarity = similarity
+	}
+	return nil
 }
 
 func validateTimeRange(opt *utils.StickerOptions) error {
 	if opt.StartTime == "" && opt.EndTime != "" {
-		return errors.New("End Time given, but Start Time not")
+		return i18n.Errorf("sticker.end_without_start")
 	}
 	if (opt.StartTime != "" && !utils.IsValidTimeFormat(opt.StartTime)) ||
 		(opt.EndTime != "" && !utils.IsValidTimeFormat(opt.EndTime)) {
-		return errors.New("Invalid time format. Use MM:SS, e.g., start=00:10 end=00:20")
+		return i18n.Errorf("sticker.invalid_time")
 	}
 	if utils.ParseTimeFromString(opt.StartTime) >= utils.ParseTimeFromString(opt.EndTime) {
-		return errors.New("Start time must be earlier than end time")
+		return i18n.Errorf("sticker.start_after_end")
 	}
 	return nil
 }
 
-func getMedia(ctx context.Context, s *state.MessageState, messageText string) (string, bool, error) {
-	if s.VMessage.GetImageMessage() != nil || s.VMessage.GetVideoMessage() != nil {
-		return getWaMedia(s)
+// mediaSource is one item of a sticker request. Fetch saves it to a local
+// file and reports whether it is animated. Hash is the SHA-256 of the file
+// when WhatsApp already told us, so a cached sticker can be sent without
+// downloading it.
+type mediaSource struct {
+	Hash       []byte
+	IsAnimated bool
+	Fetch      func(ctx context.Context) (string, bool, error)
+}
+
+// getMediaSources collects the items to make stickers from: every item of an
+// album captioned with the command, the attached or quoted media, or else
+// every link in the message.
+func getMediaSources(ctx context.Context, s *state.MessageState, messageText string) ([]mediaSource, error) {
+	if items := state.Albums.Wait(ctx, s.Inbound, 2*time.Second, 10*time.Second); len(items) > 1 {
+		var sources []mediaSource
+		for _, item := range items {
+			hash, isAnimated := s.MediaSHA256(item.Message)
+			sources = append(sources, mediaSource{hash, isAnimated, func(ctx context.Context) (string, bool, error) {
+				return saveWaMedia(s.DownloadMessageMedia(item.Message))
+			}})
+		}
+		return sources, nil
 	}
-	return getMediaFromUrl(ctx, messageText)
+
+	if s.HasDownloadableMedia() {
+		hash, isAnimated := s.MediaSHA256(nil)
+		return []mediaSource{{hash, isAnimated, func(ctx context.Context) (string, bool, error) {
+			return getWaMedia(s)
+		}}}, nil
+	}
+
+	return getUrlSources(ctx, messageText)
 }
 
 func validateVideoDuration(ctx context.Context, s *state.MessageState, path string, opt *utils.StickerOptions) bool {
@@ -147,9 +428,9 @@ func validateVideoDuration(ctx context.Context, s *state.MessageState, path stri
 	duration, err := utils.GetMediaDuration(path)
 	if err != nil {
 		if errors.Is(err, utils.ErrorNotVideo) {
-			s.ReplyNoCancelError(ctx, err, "Not a video but given start time")
+			s.ReplyNoCancelError(ctx, err, s.T("sticker.not_video"))
 		} else {
-			s.ReplyNoCancelError(ctx, err, "Server error: failed to convert sticker")
+			s.ReplyNoCancelError(ctx, err, s.T("sticker.convert_failed"))
 		}
 		utils.LogNoCancelErr(ctx, err, "error:")
 		return false
@@ -159,11 +440,11 @@ func validateVideoDuration(ctx context.Context, s *state.MessageState, path stri
 	end := utils.ParseTimeFromString(opt.EndTime)
 
 	if start > duration {
-		s.Reply(fmt.Sprintf("Start Time (%.0fs) exceeds media duration (%.0fs)", start, duration))
+		s.ReplyT("sticker.start_exceeds", start, duration)
 		return false
 	}
 	if opt.EndTime != "" && end > duration {
-		s.Reply(fmt.Sprintf("End Time (%.0fs) exceeds media duration (%.0fs)", end, duration))
+		s.ReplyT("sticker.end_exceeds", end, duration)
 		return false
 	}
 
@@ -174,25 +455,30 @@ func handleMediaError(ctx context.Context, s *state.MessageState, err error) {
 	utils.LogNoCancelErr(ctx, err, "Error getting media:")
 	switch {
 	case errors.Is(err, utils.ErrorNotSupportedLink):
-		s.ReplyNoCancelError(ctx, err, "Link not supported")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.link_unsupported"))
 	case errors.Is(err, ErrorNoLinkProvided):
-		s.ReplyNoCancelError(ctx, err, "No Link Provided")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.no_link"))
 	case errors.Is(err, utils.ErrorPageNumberExceeded):
-		s.ReplyNoCancelError(ctx, err, "Page Number Exceed the Available Pages")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.page_exceeded"))
 	case errors.Is(err, utils.ErrorPageNumberNotGiven):
-		s.ReplyNoCancelError(ctx, err, "No Page Number Given, type page=<number>")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.page_not_given"))
+	case errors.Is(err, utils.ErrorInvalidPageRange):
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.invalid_page"))
 	default:
-		s.ReplyNoCancelError(ctx, err, "Invalid Media / Link")
+		s.ReplyNoCancelError(ctx, err, s.T("sticker.invalid_media"))
 	}
 }
 
 func getWaMedia(s *state.MessageState) (string, bool, error) {
-	data, isAnimated, err := s.GetDownloadableMedia()
+	return saveWaMedia(s.GetDownloadableMedia())
+}
+
+func saveWaMedia(data []byte, isAnimated bool, err error) (string, bool, error) {
 	if err != nil {
 		return "", false, err
 	}
 
-	mediaPath := fmt.Sprintf("media/%d", time.Now().UnixMilli())
+	mediaPath := fmt.Sprintf("media/%d", time.Now().UnixNano())
 
 	err = os.WriteFile(mediaPath, data, 0644)
 	if err != nil {
@@ -204,34 +490,44 @@ func getWaMedia(s *state.MessageState) (string, bool, error) {
 
 var ErrorNoLinkProvided = errors.New("no link provided")
 
-func getMediaFromUrl(ctx context.Context, messageText string) (string, bool, error) {
-	url, err := utils.GetLinkFromString(messageText)
-	if err != nil {
-		return "", false, ErrorNoLinkProvided
+// getUrlSources resolves every link in the message. Instagram carousels are
+// expanded to the pages picked with page=N, page=1-4 or page=all.
+func getUrlSources(ctx context.Context, messageText string) ([]mediaSource, error) {
+	urls := utils.GetLinksFromString(messageText)
+	if len(urls) == 0 {
+		return nil, ErrorNoLinkProvided
 	}
 
-	page := func() int {
-		re := regexp.MustCompile(`\s+page=(\d+)(\s+|$)`)
-		matches := re.FindStringSubmatch(messageText)
-
-		if len(matches) < 2 {
-			return 0
-		}
-		num, err := strconv.Atoi(matches[1])
+	var pages utils.PageRange
+	if matches := regexp.MustCompile(`\s+page=(\S+)(\s+|$)`).FindStringSubmatch(messageText); len(matches) >= 2 {
+		var err error
+		pages, err = utils.ParsePageRange(matches[1])
 		if err != nil {
-			return 0
+			return nil, err
 		}
-		return num
 	}
 
-	if strings.Contains(url, "instagram.com") {
-		igpage := page()
-		url, err = utils.GetInstagramDirectURL(url, igpage)
-		if err != nil {
-			return "", false, err
+	var sources []mediaSource
+	for _, url := range urls {
+		directURLs := []string{url}
+		if strings.Contains(url, "instagram.com") {
+			var err error
+			directURLs, err = utils.GetInstagramDirectURLs(url, pages)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, directURL := range directURLs {
+			sources = append(sources, mediaSource{Fetch: func(ctx context.Context) (string, bool, error) {
+				return getMediaFromUrl(ctx, directURL)
+			}})
 		}
 	}
+	return sources, nil
+}
 
+func getMediaFromUrl(ctx context.Context, url string) (string, bool, error) {
 	mediaPath, mimeType, err := utils.DownloadMediaFromURL(ctx, url)
 	if err != nil {
 		return mediaPath, false, err
@@ -241,10 +537,24 @@ func getMediaFromUrl(ctx context.Context, messageText string) (string, bool, err
 	return mediaPath, isAnimated, nil
 }
 
-func sendMediaAsSticker(ctx context.Context, s *state.MessageState, mediaPath string, opt *utils.StickerOptions) error {
+// sendMediaAsSticker converts a media file and sends it as a sticker. The
+// conversion is cached by sourceHash and the options. An empty sourceHash means
+// the cache was not checked yet, so the file is hashed and looked up here.
+func sendMediaAsSticker(ctx context.Context, s *state.MessageState, mediaPath string, sourceHash []byte, opt *utils.StickerOptions) error {
 	var err error
 
-	webpPath, err := utils.ConvertToWebp(ctx, mediaPath, opt)
+	if len(sourceHash) == 0 {
+		sourceHash, err = utils.HashFile(mediaPath)
+		if err != nil {
+			return fmt.Errorf("hash media: %w", err)
+		}
+		if sendCachedSticker(ctx, s, sourceHash, opt) {
+			return nil
+		}
+	}
+	cacheKey, cacheErr := utils.StickerCacheKey(sourceHash, opt)
+
+	webpPath, compromises, err := utils.FitWebp(ctx, mediaPath, opt)
 	defer os.Remove(webpPath)
 	if err != nil {
 		if errors.Is(err, utils.ErrorNotUnder1MB) {
@@ -254,8 +564,64 @@ func sendMediaAsSticker(ctx context.Context, s *state.MessageState, mediaPath st
 		}
 	}
 
-	author := os.Getenv("APP_NAME")
-	finalWebpPath, err := utils.WriteWebpExifFile(ctx, webpPath, "+62 812-3436-3620", author)
+	if cacheErr == nil {
+		if err := storage.PutCachedSticker(cacheKey, webpPath); err != nil {
+			fmt.Println("Error caching sticker:", err)
+		}
+	}
+
+	err = sendWebpSticker(ctx, s, webpPath, stickerMetadata(s, opt.Metadata), opt.IsAnimated)
+	if err != nil {
+		return err
+	}
+
+	if len(compromises) > 0 {
+		s.Reply(formatCompromises(s, compromises))
+	}
+
+	return nil
+}
+
+// sendCachedSticker sends the cached conversion of the source with the given
+// options, and reports whether it did.
+func sendCachedSticker(ctx context.Context, s *state.MessageState, sourceHash []byte, opt *utils.StickerOptions) bool {
+	if len(sourceHash) == 0 {
+		return false
+	}
+
+	cacheKey, err := utils.StickerCacheKey(sourceHash, opt)
+	if err != nil {
+		return false
+	}
+	webpPath, err := storage.GetCachedSticker(cacheKey)
+	if err != nil {
+		if !errors.Is(err, storage.ErrorCacheMiss) {
+			fmt.Println("Error reading sticker cache:", err)
+		}
+		return false
+	}
+
+	err = sendWebpSticker(ctx, s, webpPath, stickerMetadata(s, opt.Metadata), opt.IsAnimated)
+	if err != nil {
+		utils.LogNoCancelErr(ctx, err, "Error sending cached sticker:")
+		return false
+	}
+	return true
+}
+
+func formatCompromises(s *state.MessageState, compromises []utils.Compromise) string {
+	var changes []string
+	for _, c := range compromises {
+		changes = append(changes, s.T("sticker.fit_"+c.Setting, c.From, c.To))
+	}
+	return s.T("sticker.fitted", strings.Join(changes, ", "))
+}
+
+// sendWebpSticker writes the EXIF metadata into a finished WebP and sends it.
+// Only the EXIF chunk is rewritten, so the image data and animation are sent
+// untouched.
+func sendWebpSticker(ctx context.Context, s *state.MessageState, webpPath string, metadata utils.StickerMetadata, isAnimated bool) error {
+	finalWebpPath, err := utils.WriteWebpExifFile(ctx, webpPath, metadata)
 	defer os.Remove(finalWebpPath)
 	if err != nil {
 		return fmt.Errorf("write EXIF: %w", err)
@@ -269,15 +635,36 @@ func sendMediaAsSticker(ctx context.Context, s *state.MessageState, mediaPath st
 		return fmt.Errorf("read WebP: %w", err)
 	}
 
-	uploadedData, err := s.UploadToWhatsapp(ctx, webpData, "image")
-	if err != nil {
-		return fmt.Errorf("upload to WhatsApp: %w", err)
+	// An identical final WebP is re-sent by the direct path of its earlier
+	// upload. The EXIF carries the sender's pack ID, so this only saves the
+	// upload when the same user repeats a request; across users only the
+	// conversion is shared.
+	sum := sha256.Sum256(webpData)
+	uploadKey := hex.EncodeToString(sum[:])
+	uploadedData, err := storage.GetCachedUpload(uploadKey)
+	cached := err == nil
+	if err != nil && !errors.Is(err, storage.ErrorCacheMiss) {
+		fmt.Println("Error reading upload cache:", err)
 	}
 
-	err = s.SendStickerMessage(ctx, uploadedData, opt.IsAnimated)
+	if !cached {
+		uploadedData, err = s.UploadToWhatsapp(ctx, webpData, "image")
+		if err != nil {
+			return fmt.Errorf("upload to WhatsApp: %w", err)
+		}
+	}
+
+	err = s.SendStickerMessage(ctx, uploadedData, isAnimated)
 	if err != nil {
 		return fmt.Errorf("send sticker: %w", err)
 	}
 
+	if !cached {
+		if err := storage.SaveCachedUpload(uploadKey, uploadedData); err != nil {
+			fmt.Println("Error caching upload:", err)
+		}
+	}
+
+	recentStickers.Put(s.SenderJID.String(), webpData)
 	return nil
 }
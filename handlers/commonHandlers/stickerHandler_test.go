@@ -0,0 +1,288 @@
+package commonHandlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wa-bot/commands"
+	"wa-bot/i18n"
+	"wa-bot/state"
+	"wa-bot/state/statetest"
+	"wa-bot/storage"
+	"wa-bot/utils"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	testGroup = waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	testUser  = waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+)
+
+// setupHandlerTest runs the test in an empty directory with a fresh database
+// and a FakeConverter, so no binaries or network are needed.
+func setupHandlerTest(t *testing.T) *utils.FakeConverter {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("media", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Open("file:" + filepath.Join(dir, "bot.db")); err != nil {
+		t.Fatal(err)
+	}
+
+	converter := &utils.FakeConverter{}
+	restore := utils.UseFakeConverter(converter)
+	t.Cleanup(func() {
+		restore()
+		storage.DB.Close()
+		os.Chdir(wd)
+	})
+	return converter
+}
+
+func newTestState(messenger *statetest.FakeMessenger, chat, sender waTypes.JID, text string, msg *waProto.Message) *state.MessageState {
+	if msg == nil {
+		msg = &waProto.Message{Conversation: proto.String(text)}
+	}
+	in := &state.InboundMessage{
+		ID:          "TEST",
+		Timestamp:   time.Now(),
+		ChatJID:     chat,
+		SenderJID:   sender,
+		IsFromGroup: chat.Server == waTypes.GroupServer,
+		Message:     msg,
+		Text:        text,
+	}
+	return &state.MessageState{
+		Messenger:   messenger,
+		Inbound:     in,
+		VMessage:    msg,
+		ChatJID:     chat,
+		SenderJID:   sender,
+		MessageText: text,
+		IsFromGroup: in.IsFromGroup,
+		UserRole:    "COMMON",
+		Language:    "en",
+	}
+}
+
+// waitForJob waits until the background job started by a handler is done.
+func waitForJob(t *testing.T, s *state.MessageState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.CheckUserState() != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("job did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func testPng(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// imageMessage builds an image message whose media the messenger can
+// download.
+func imageMessage(messenger *statetest.FakeMessenger, caption string, data []byte) *waProto.Message {
+	sum := sha256.Sum256(data)
+	if messenger.Media == nil {
+		messenger.Media = make(map[string][]byte)
+	}
+	messenger.Media[hex.EncodeToString(sum[:])] = data
+	return &waProto.Message{ImageMessage: &waProto.ImageMessage{
+		Caption:    proto.String(caption),
+		Mimetype:   proto.String("image/png"),
+		FileSHA256: sum[:],
+	}}
+}
+
+func lastText(t *testing.T, messenger *statetest.FakeMessenger) string {
+	t.Helper()
+	texts := messenger.Texts()
+	if len(texts) == 0 {
+		t.Fatal("nothing was replied")
+	}
+	return texts[len(texts)-1]
+}
+
+func TestStickerHandlerImage(t *testing.T) {
+	converter := setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+
+	text := "!sticker quality=50 nocrop pack=Mine"
+	s := newTestState(messenger, testGroup, testUser, text, imageMessage(messenger, text, testPng(t, color.White)))
+	StickerHandler(s)
+	waitForJob(t, s)
+
+	stickers := messenger.Stickers()
+	if len(stickers) != 1 {
+		t.Fatalf("sent %d stickers, replies %q", len(stickers), messenger.Texts())
+	}
+	if len(converter.Conversions) != 1 {
+		t.Fatalf("converted %d times", len(converter.Conversions))
+	}
+	if opt := converter.Conversions[0]; opt.Quality != 50 || !opt.NoCrop || opt.IsAnimated {
+		t.Errorf("converted with %+v", opt)
+	}
+
+	metadata, _, err := utils.ReadWebpMetadata(messenger.Uploads[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.PackName != "Mine" {
+		t.Errorf("pack name %q", metadata.PackName)
+	}
+}
+
+func TestStickerHandlerReusesCache(t *testing.T) {
+	converter := setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	data := testPng(t, color.White)
+
+	for range 2 {
+		s := newTestState(messenger, testGroup, testUser, "!sticker", imageMessage(messenger, "!sticker", data))
+		StickerHandler(s)
+		waitForJob(t, s)
+	}
+
+	stickers := messenger.Stickers()
+	if len(stickers) != 2 {
+		t.Fatalf("sent %d stickers", len(stickers))
+	}
+	if len(converter.Conversions) != 1 || messenger.Downloads != 1 {
+		t.Errorf("converted %d times and downloaded %d times, want once", len(converter.Conversions), messenger.Downloads)
+	}
+	if len(messenger.Uploads) != 1 || stickers[0].GetDirectPath() != stickers[1].GetDirectPath() {
+		t.Errorf("uploaded %d times, want the first upload re-sent", len(messenger.Uploads))
+	}
+}
+
+func TestStickerHandlerSharesConversionAcrossUsers(t *testing.T) {
+	converter := setupHandlerTest(t)
+	messenger := &statetest.FakeMessenger{}
+	data := testPng(t, color.White)
+
+	for _, sender := range []waTypes.JID{testUser, waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)} {
+		s := newTestState(messenger, testGroup, sender, "!sticker", imageMessage(messenger, "!sticker", data))
+		StickerHandler(s)
+		waitForJob(t, s)
+	}
+
+	if len(messenger.Stickers()) != 2 {
+		t.Fatalf("sent %d stickers", len(messenger.Stickers()))
+	}
+	if len(converter.Conversions) != 1 {
+		t.Errorf("converted %d times, want once", len(converter.Conversions))
+	}
+	// Each user's pack ID is in the EXIF, so the final files differ.
+	if len(messenger.Uploads) != 2 {
+		t.Errorf("uploaded %d times, want once per user", len(messenger.Uploads))
+	}
+}
+
+func TestStickerHandlerLink(t *testing.T) {
+	converter := setupHandlerTest(t)
+	converter.Downloads = map[string][]byte{"https://example.com/cat.png": testPng(t, color.Black)}
+	messenger := &statetest.FakeMessenger{}
+
+	s := newTestState(messenger, testUser, testUser, "!sticker https://example.com/cat.png", nil)
+	StickerHandler(s)
+	waitForJob(t, s)
+
+	if len(messenger.Stickers()) != 1 {
+		t.Fatalf("no sticker sent, replies %q", messenger.Texts())
+	}
+	if len(converter.URLs) != 1 || converter.URLs[0] != "https://example.com/cat.png" {
+		t.Errorf("downloaded %q", converter.URLs)
+	}
+}
+
+func TestStickerHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		media    bool
+		webp     []byte
+		duration float64
+		want     string
+	}{
+		{"invalid fps", "!sticker fps=100", true, nil, 0, i18n.T("en", "sticker.invalid_fps")},
+		{"no media or link", "!sticker", false, nil, 0, i18n.T("en", "sticker.no_link")},
+		{"unsupported link", "!sticker https://example.com/page", false, nil, 0, i18n.T("en", "sticker.link_unsupported")},
+		{"too large", "!sticker", true, make([]byte, 2<<20), 0, i18n.T("en", "sticker.not_under_1mb")},
+		{"start on an image", "!sticker start=00:01", true, nil, 0, i18n.T("en", "sticker.not_video")},
+		{"start after the end", "!sticker start=00:10", true, nil, 5, i18n.T("en", "sticker.start_exceeds", 10.0, 5.0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := setupHandlerTest(t)
+			converter.Webp = tt.webp
+			converter.Duration = tt.duration
+			messenger := &statetest.FakeMessenger{}
+
+			var msg *waProto.Message
+			if tt.media {
+				msg = imageMessage(messenger, tt.text, testPng(t, color.White))
+			}
+			s := newTestState(messenger, testGroup, testUser, tt.text, msg)
+			StickerHandler(s)
+			waitForJob(t, s)
+
+			if got := lastText(t, messenger); got != tt.want {
+				t.Errorf("replied %q, want %q", got, tt.want)
+			}
+			if len(messenger.Stickers()) != 0 {
+				t.Error("a sticker was sent")
+			}
+		})
+	}
+}
+
+func TestStickerCommandsAllowEveryRole(t *testing.T) {
+	messenger := &statetest.FakeMessenger{}
+
+	for _, cmd := range commands.All() {
+		if cmd.Category != "help.cat_sticker" {
+			continue
+		}
+		for _, role := range utils.Roles {
+			s := newTestState(messenger, testGroup, testUser, "!"+cmd.Name, nil)
+			s.UserRole = role
+			if allowed, _ := cmd.Authorize(s, time.Now()); !allowed {
+				t.Errorf("%s is denied to %s", cmd.Name, role)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package commonHandlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/storage"
+	"wa-bot/utils"
+)
+
+const (
+	defaultPackName = "+62 812-3436-3620"
+	maxMetadataLen  = 64
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "packname",
+		Args:     `(\s+.+)?`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.packname"},
+		Handler:  PackNameHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "author",
+		Args:     `(\s+.+)?`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.author"},
+		Handler:  AuthorHandler,
+	})
+}
+
+func PackNameHandler(s *state.MessageState) {
+	updateStickerPrefs(s, "sticker.packname", func(prefs *storage.StickerPrefs) *string {
+		return &prefs.PackName
+	})
+}
+
+func AuthorHandler(s *state.MessageState) {
+	updateStickerPrefs(s, "sticker.author", func(prefs *storage.StickerPrefs) *string {
+		return &prefs.Author
+	})
+}
+
+// updateStickerPrefs shows, sets or resets the preference field returns.
+func updateStickerPrefs(s *state.MessageState, label string, field func(*storage.StickerPrefs) *string) {
+	prefs, err := storage.GetStickerPrefs(s.SenderJID.String())
+	if err != nil {
+		fmt.Println("Error getting sticker prefs:", err)
+		s.ReplyT("sticker.prefs_failed")
+		return
+	}
+
+	value := strings.TrimSpace(regexp.MustCompile(`^\S+`).ReplaceAllString(s.MessageText, ""))
+	if value == "" {
+		current := *field(&prefs)
+		if current == "" {
+			current = "-"
+		}
+		s.ReplyT("sticker.prefs_current", s.T(label), current)
+		return
+	}
+	if len([]rune(value)) > maxMetadataLen {
+		s.ReplyT("sticker.prefs_too_long", maxMetadataLen)
+		return
+	}
+	if strings.EqualFold(value, "reset") {
+		value = ""
+	}
+
+	*field(&prefs) = value
+	if err := storage.SaveStickerPrefs(prefs); err != nil {
+		fmt.Println("Error saving sticker prefs:", err)
+		s.ReplyT("sticker.prefs_failed")
+		return
+	}
+
+	if value == "" {
+		s.ReplyT("sticker.prefs_reset", s.T(label))
+	} else {
+		s.ReplyT("sticker.prefs_set", s.T(label), value)
+	}
+}
+
+// stickerMetadata fills the EXIF metadata of a sticker made by the sender:
+// inline options first, then their saved defaults, then the bot defaults.
+func stickerMetadata(s *state.MessageState, inline utils.StickerMetadata) utils.StickerMetadata {
+	prefs, err := storage.GetStickerPrefs(s.SenderJID.String())
+	if err != nil {
+		fmt.Println("Error getting sticker prefs:", err)
+	}
+
+	return utils.StickerMetadata{
+		PackID:    userPackID(s.SenderJID.String()),
+		PackName:  firstNonEmpty(inline.PackName, prefs.PackName, defaultPackName),
+		Publisher: firstNonEmpty(inline.Publisher, prefs.Author, os.Getenv("APP_NAME")),
+		Emojis:    inline.Emojis,
+	}
+}
+
+// userPackID derives a stable pack ID per user without exposing their
+// number in the sticker file.
+func userPackID(jid string) string {
+	sum := sha256.Sum256([]byte(jid))
+	return "site.alyza.custompack." + hex.EncodeToString(sum[:6])
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package commonHandlers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "take",
+		Args:     `(\s+.+)?`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage: []string{
+			"help.take",
+			"help.take_custom",
+		},
+		Handler: TakeHandler,
+	})
+}
+
+func TakeHandler(s *state.MessageState) {
+	sticker := s.QuotedMessage().GetStickerMessage()
+	if sticker == nil {
+		s.ReplyT("sticker.take_no_sticker")
+		return
+	}
+
+	var inline utils.StickerMetadata
+	if value := strings.TrimSpace(regexp.MustCompile(`^\S+`).ReplaceAllString(s.MessageText, "")); value != "" {
+		parts := strings.SplitN(value, "|", 2)
+		inline.PackName = strings.TrimSpace(parts[0])
+		if len(parts) == 2 {
+			inline.Publisher = strings.TrimSpace(parts[1])
+		}
+		if len([]rune(inline.PackName)) > maxMetadataLen || len([]rune(inline.Publisher)) > maxMetadataLen {
+			s.ReplyT("sticker.prefs_too_long", maxMetadataLen)
+			return
+		}
+	}
+
+	if !checkStickerQuota(s) {
+		return
+	}
+
+	convertQuotedSticker(s, func(ctx context.Context, webpPath string) error {
+		err := sendWebpSticker(ctx, s, webpPath, stickerMetadata(s, inline), sticker.GetIsAnimated())
+		if err == nil && !utils.IsCanceledGoroutine(ctx) {
+			countStickerUsage(s)
+		}
+		return err
+	})
+}
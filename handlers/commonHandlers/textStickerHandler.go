@@ -0,0 +1,76 @@
+package commonHandlers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"wa-bot/commands"
+	"wa-bot/state"
+	"wa-bot/utils"
+)
+
+func init() {
+	commands.Register(&commands.Command{
+		Name:     "ttp",
+		Args:     `\s+[\s\S]+`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.ttp"},
+		Handler:  TTPHandler,
+	})
+	commands.Register(&commands.Command{
+		Name:     "attp",
+		Args:     `\s+[\s\S]+`,
+		Roles:    stickerRoles,
+		Category: "help.cat_sticker",
+		Usage:    []string{"help.attp"},
+		Handler:  TTPHandler,
+	})
+}
+
+func TTPHandler(s *state.MessageState) {
+	animated := strings.HasPrefix(strings.ToLower(s.MessageText), "!attp")
+	text := strings.TrimSpace(regexp.MustCompile(`^\S+`).ReplaceAllString(s.MessageText, ""))
+
+	if !checkStickerQuota(s) {
+		return
+	}
+	s.ReplyT("common.loading")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.AddUserToState("processing", cancel)
+
+	go func() {
+		defer s.ClearUserState()
+		defer cancel()
+
+		render := utils.RenderTextPng
+		opt := &utils.StickerOptions{}
+		if animated {
+			render = utils.RenderTextGif
+			opt = &utils.StickerOptions{IsAnimated: true, FPS: 10}
+		}
+
+		mediaPath, err := render(text)
+		defer os.Remove(mediaPath)
+		if errors.Is(err, utils.ErrorNoRenderableText) {
+			s.ReplyT("sticker.no_renderable_text")
+			return
+		} else if err != nil {
+			utils.LogNoCancelErr(ctx, err, "error:")
+			s.ReplyT("sticker.convert_failed")
+			return
+		}
+
+		err = sendMediaAsSticker(ctx, s, mediaPath, nil, opt)
+		if err == nil && !utils.IsCanceledGoroutine(ctx) {
+			countStickerUsage(s)
+		}
+		if err != nil {
+			replyStickerError(ctx, s, err)
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	if missing := MissingKeys(); len(missing) > 0 {
+		t.Fatalf("missing translations: %v", missing)
+	}
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0]*[0-9.]*[a-zA-Z%]`)
+
+// Every translation must take the same arguments as the English message, or
+// T would format it with %!(EXTRA ...) or %!v(MISSING).
+func TestCatalogsHaveSameVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, message := range catalog {
+			english, exists := catalogs[FallbackLanguage][key]
+			if !exists {
+				continue
+			}
+			if got, want := verbRe.FindAllString(message, -1), verbRe.FindAllString(english, -1); !slices.Equal(got, want) {
+				t.Errorf("%s:%s has verbs %q, want %q", lang, key, got, want)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		lang, key string
+		args      []any
+		want      string
+	}{
+		{"en", "common.hello", nil, "Hello, World!"},
+		{"xx", "common.hello", nil, "Hello, World!"},
+		{"en", "no.such.key", nil, "no.such.key"},
+		{"id", "role.until", []any{"2026-01-02 15:04"}, " (sampai 2026-01-02 15:04)"},
+	}
+
+	for _, tt := range tests {
+		if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package i18n
+
+var en = map[string]string{
+	"common.loading":          "⏳ Loading...",
+	"common.hello":            "Hello, World!",
+	"common.invalid_command":  "Invalid Command",
+	"common.busy":             "There is another process, !cancel to cancel it",
+	"common.denied":           "⛔ You are not allowed to use this command here",
+	"common.command_disabled": "⛔ This command is disabled in this group",
+	"common.help_hint":        "!help to see the command list",
+	"common.group_only":       "This command can only be used in a group",
+	"common.invalid_number":   "Invalid number",
+
+	"help.title": "*LIST COMMANDS*",
+
+	"help.cat_general": "General",
+	"help.cat_sticker": "Sticker",
+	"help.cat_group":   "Group",
+	"help.cat_admin":   "Admin",
+	"help.cat_owner":   "Owner",
+	"help.cat_user":    "User",
+
+	"help.help":           "`!help` // Show this command list",
+	"help.reloadacl":      "`!reloadacl` // Reload the command access policy file",
+	"help.check":          "`!check` // Check if the bot is alive",
+	"help.cancel":         "`!cancel` // Cancel your running process",
+	"help.lang":           "`!lang <id/en>` // Set your language",
+	"help.group_settings": "`!group settings` // Show this group's settings",
+	"help.group_toggle":   "`!group enable` / `!group disable` // Turn the bot on/off here",
+	"help.group_command":  "`!group enable <command>` / `!group disable <command>`",
+	"help.group_lang":     "`!group lang <id/en>` // Group language",
+	"help.group_prefix":   "`!group prefix <symbol>` // Command prefix, default `!`",
+	"help.group_quota":    "`!group quota <N>` // Daily stickers per member, 0 = unlimited",
+	"help.group_welcome":  "`!group welcome <text/off>` // Welcome text, {user} and {group} are replaced",
+	"help.group_allow":    "`!group allow [group JID]` / `!group deny [group JID]` // Owner only",
+	"help.sticker_url":    "`!sticker <video/gif/image URL>` // From URL",
+	"help.sticker_media":  "`!sticker` // Send with, or reply to, an image/video/gif/sticker",
+	"help.sticker_urls":   "`!sticker <URL> <URL> ...` // One sticker per link, up to 10",
+	"help.sticker_album":  "`!sticker` as the caption of an album // One sticker per album item",
+	"help.take":           "`!take` // Reply to a sticker to save it under your pack name and author",
+	"help.take_custom":    "`!take <pack>|<author>` // Reply to a sticker with a custom pack name and author",
+	"help.pack_create":    "`!pack create <name>` // Create a sticker pack, or switch to an existing one",
+	"help.pack_add":       "`!pack add` // Reply to a sticker, or send right after making one, to add it to your current pack",
+	"help.pack_list":      "`!pack list` // Your sticker packs",
+	"help.pack_export":    "`!pack export [name]` // Download a pack as a .wastickers file for sticker apps",
+	"help.qc":             "`!qc` // Reply to a text message to turn it into a chat bubble sticker",
+	"help.qc_text":        "`!qc <text>` // Chat bubble sticker of your own text",
+	"help.ttp":            "`!ttp <text>` // Text to sticker",
+	"help.attp":           "`!attp <text>` // Text to animated sticker",
+	"help.toimg":          "`!toimg` // Reply to a sticker to turn it into an image",
+	"help.tovid":          "`!tovid` // Reply to an animated sticker to turn it into a video",
+	"help.togif":          "`!togif` // Reply to an animated sticker to turn it into a GIF",
+	"help.smeme":          "`!smeme <top text>|<bottom text>` // Sticker with meme captions, takes the same media and options as !sticker",
+	"help.smeme_bottom":   "`!smeme |<bottom text>` // Bottom caption only",
+	"help.packname":       "`!packname <text/reset>` // Default pack name of your stickers",
+	"help.author":         "`!author <text/reset>` // Default author of your stickers",
+	"help.listgroups":     "`!listgroups` // List joined groups",
+	"help.refreshgroups":  "`!refreshgroups` // Reload the group membership cache",
+	"help.listmapel":      "`!listmapel`",
+	"help.pdf_number":     "`!pdf <number from !listmapel>`",
+	"help.pdf_name":       "`!pdf <subject name>`",
+	"help.answer_number":  "`!answer <number from !listmapel> <answer>`",
+	"help.answer_name":    "`!answer <subject name> <answer>`",
+	"help.gemini":         "`!gemini <subject number/name>` // Answer questions with Gemini",
+	"help.token":          "`!token`",
+	"help.grant":          "`!grant <number> <OWNER/ADMIN/USER/COMMON> [30m/12h/7d/2w]` // Grant a role",
+	"help.revoke":         "`!revoke <number>` // Remove a granted role",
+	"help.roles":          "`!roles` // List owners and granted roles",
+
+	"help.sticker_details": "_Optional parameters_ (can be added after the command or URL):\n" +
+		"- `nocrop` // Prevent auto-cropping to square\n" +
+		"- `start=MM:SS` // Start time for video/gif\n" +
+		"- `end=MM:SS` // End time for video/gif\n" +
+		"- `fps=N` // Frame per second (1-60)\n" +
+		"- `quality=N` // Output quality (1-100)\n" +
+		"- `page=N` // Instagram carousel page, or a range such as `page=1-4` or `page=all`\n" +
+		"- `direction=side` // Pan direction: up, down, left, right\n" +
+		"- `direction=side-N` // Pan with offset (0-50), e.g., `right-25`\n" +
+		"- `pack=name` / `author=name` // Sticker metadata, use quotes for spaces: `pack=\"My Pack\"`\n" +
+		"- `emoji=😂,🔥` // Up to 3 emoji tags\n" +
+		"- `shape=circle` // Shape: circle, rounded or square\n" +
+		"- `border=white` // Border color, a name or hex such as `#FF0000`\n" +
+		"- `flip` / `mirror` // Flip upside down / left to right\n" +
+		"- `rotate=90` // Rotate clockwise: 90, 180 or 270\n" +
+		"- `grayscale` // Black and white\n" +
+		"- `bg=remove` // Remove a solid background, detected from the corners\n" +
+		"- `bg=remove:white:0.2` // Remove a given color, with an optional tolerance (0.01-1, default 0.15)\n" +
+		"- `speed=2x` // Playback speed for video/gif (0.5x-4x)\n" +
+		"- `reverse` / `boomerang` // Play video/gif backwards / forwards then backwards\n" +
+		"\n" +
+		"Stickers over 1MB are shrunk automatically; quality, fps and start/end you set yourself are kept.\n" +
+		"\n" +
+		"*Examples:*\n" +
+		"1. !sticker https://demo.alyza.site nocrop start=00:00 end=00:02 fps=24 quality=80\n" +
+		"2. !sticker https://demo.alyza.site/ direction=left-30 quality=90\n" +
+		"3. !sticker shape=circle border=white boomerang",
+
+	"cancel.none":    "❌ There is no running process",
+	"cancel.failed":  "⚠️ Failed to cancel process",
+	"cancel.success": "✅ Process successfully cancelled",
+
+	"lang.current": "🌐 Your language: %s\nChange it with !lang <%s>",
+	"lang.set":     "✅ Language set to %s",
+	"lang.usage":   "Usage: !lang <%s>",
+	"lang.failed":  "⚠️ Failed to save language",
+
+	"policy.reload_failed": "⚠️ Failed to reload policy: %s",
+	"policy.reloaded":      "✅ Policy reloaded",
+
+	"groups.list_title":     "📌 *Group List:*",
+	"groups.list_item":      "📂 *%s*\n📎 ID: %s",
+	"groups.list_failed":    "⚠️ Failed to fetch group list",
+	"groups.refresh_failed": "⚠️ Failed to refresh group cache",
+	"groups.refreshed":      "✅ Group cache refreshed (%d groups)",
+
+	"mapel.fetch_failed":   "Failed to fetch the subject list.",
+	"mapel.list_item":      "%d. %s",
+	"mapel.invalid_number": "Invalid subject number.",
+	"mapel.invalid":        "Invalid subject.",
+	"pdf.bad_format":       "Invalid command format",
+	"pdf.failed":           "Failed to fetch the PDF",
+	"gemini.upload_failed": "Failed to re-upload the file. Try again",
+
+	"token.ask_name":       "Please enter your full name.",
+	"token.timeout":        "⏳ Time is up! Please type *!token* again.",
+	"token.invalid_name":   "⚠️ Invalid name",
+	"token.unknown_number": "⚠️ Your number could not be recognized, try again later.",
+	"token.failed":         "Failed to get a token.",
+	"token.new":            "✅ Your new token is:",
+	"token.updated":        "Your old token is no longer valid. This is your new token:",
+
+	"role.invalid_role":     "Invalid role. Use OWNER, ADMIN, USER or COMMON",
+	"role.invalid_duration": "Invalid duration. Use e.g. 30m, 12h, 7d or 2w",
+	"role.grant_failed":     "⚠️ Failed to grant role",
+	"role.granted":          "✅ %s is now %s",
+	"role.granted_until":    "✅ %s is now %s until %s",
+	"role.revoke_failed":    "⚠️ Failed to revoke role",
+	"role.not_granted":      "❌ %s has no granted role",
+	"role.revoked":          "✅ Role of %s revoked",
+	"role.list_failed":      "⚠️ Failed to list roles",
+	"role.owners_title":     "👑 *Owners (.env):*",
+	"role.grants_title":     "📌 *Granted roles:*",
+	"role.until":            " (until %s)",
+	"role.owner_item":       "- %s",
+	"role.grant_item":       "- %s: %s",
+	"role.grants_none":      "- (none)",
+
+	"group.load_failed":   "⚠️ Failed to load group settings",
+	"group.save_failed":   "⚠️ Failed to save group settings",
+	"group.admin_only":    "⛔ Only group admins can change the group settings",
+	"group.owner_only":    "⛔ Only the owner can allow or deny groups",
+	"group.enabled":       "✅ Bot enabled in this group",
+	"group.disabled":      "✅ Bot disabled in this group",
+	"group.unknown_cmd":   "Unknown command: %s",
+	"group.cmd_enabled":   "✅ !%s enabled in this group",
+	"group.cmd_disabled":  "✅ !%s disabled in this group",
+	"group.lang_usage":    "Usage: !group lang <%s>",
+	"group.lang_set":      "✅ Group language set to %s",
+	"group.prefix_usage":  "Usage: !group prefix <symbol> (up to 3 symbols, no letters or digits)",
+	"group.prefix_set":    "✅ Command prefix set to %s",
+	"group.quota_usage":   "Usage: !group quota <N> (0 = unlimited)",
+	"group.quota_set":     "✅ Daily sticker quota set to %d",
+	"group.welcome_usage": "Usage: !group welcome <text/off>",
+	"group.welcome_set":   "✅ Welcome text updated",
+	"group.invalid_jid":   "Invalid group JID, see !listgroups",
+	"group.allow_usage":   "Usage: !group allow <group JID>",
+	"group.allowed":       "✅ Group allowed: %s",
+	"group.denied":        "✅ Group denied: %s",
+	"group.settings":      "⚙️ *Group Settings*\n\nAllowed: %s\nBot: %s\nDisabled commands: %s\nLanguage: %s\nPrefix: %s\nSticker quota: %s\nWelcome: %s",
+	"group.on":            "on",
+	"group.off":           "off",
+	"group.default":       "default",
+	"group.unlimited":     "unlimited",
+	"group.quota_per_day": "%d/day",
+
+	"sticker.quota_reached":      "⛔ Daily sticker quota reached (%d/%d), try again tomorrow",
+	"sticker.not_under_1mb":      "Failed to convert media under 1MB. Consider trying one of the following:\n- Lower the quality with: quality=<0-100>\n- Reduce the video duration: start=MM:SS end=MM:SS\n- Reduce the video FPS: fps=<1-60>",
+	"sticker.fitted":             "ℹ️ Reduced to fit the 1MB limit: %s",
+	"sticker.fit_quality":        "quality %d → %d",
+	"sticker.fit_fps":            "fps %d → %d",
+	"sticker.fit_size":           "detail reduced (%dpx → %dpx, still shown at 512px)",
+	"sticker.fit_duration":       "duration %ds → %ds",
+	"sticker.packname":           "Pack name",
+	"sticker.author":             "Author",
+	"sticker.prefs_current":      "%s: %s",
+	"sticker.prefs_set":          "✅ %s set to %s",
+	"sticker.prefs_reset":        "✅ %s reset to the default",
+	"sticker.prefs_too_long":     "Text must be at most %d characters",
+	"sticker.prefs_failed":       "⚠️ Failed to save sticker settings",
+	"sticker.too_many_emojis":    "At most 3 emojis are allowed",
+	"sticker.take_no_sticker":    "Reply to a sticker with !take",
+	"sticker.reply_to_sticker":   "Reply to a sticker with this command",
+	"sticker.not_animated":       "That sticker is not animated, use !toimg",
+	"sticker.no_renderable_text": "The text has no characters that can be drawn",
+	"sticker.smeme_usage":        "Usage: !smeme <top text>|<bottom text>",
+	"sticker.qc_usage":           "Reply to a text message with !qc, or send !qc <text>",
+	"sticker.convert_failed":     "Server error: failed to convert sticker",
+	"sticker.invalid_fps":        "FPS must be between 1 and 60",
+	"sticker.invalid_quality":    "Quality must be between 1 and 100",
+	"sticker.invalid_dir":        "Direction invalid. Use up, down, left, or right (with optional -0 to -50)",
+	"sticker.invalid_offset":     "Direction offset must be between 0 and 50",
+	"sticker.invalid_shape":      "Shape must be circle, rounded or square",
+	"sticker.invalid_color":      "Unknown color. Use a name such as white or a hex value such as #FF0000",
+	"sticker.invalid_rotate":     "Rotation must be 90, 180 or 270",
+	"sticker.invalid_speed":      "Speed must be between 0.5x and 4x",
+	"sticker.invalid_bg":         "Background option invalid. Use bg=remove, bg=remove:<color> or bg=remove:<color>:<0.01-1>",
+	"sticker.invalid_similarity": "Background tolerance must be between 0.01 and 1",
+	"sticker.end_without_start":  "End Time given, but Start Time not",
+	"sticker.invalid_time":       "Invalid time format. Use MM:SS, e.g., start=00:10 end=00:20",
+	"sticker.start_after_end":    "Start time must be earlier than end time",
+	"sticker.not_video":          "Not a video but given start time",
+	"sticker.start_exceeds":      "Start Time (%.0fs) exceeds media duration (%.0fs)",
+	"sticker.end_exceeds":        "End Time (%.0fs) exceeds media duration (%.0fs)",
+	"sticker.link_unsupported":   "Link not supported",
+	"sticker.no_link":            "No Link Provided",
+	"sticker.page_exceeded":      "Page Number Exceed the Available Pages",
+	"sticker.page_not_given":     "No Page Number Given, type page=<number> or page=all",
+	"sticker.batch_capped":       "Only the first %d of %d items will be made into stickers",
+	"sticker.batch_progress":     "⏳ Making stickers... %d/%d",
+	"sticker.batch_done":         "✅ %d of %d stickers sent",
+	"sticker.invalid_page":       "Page invalid. Use page=<number>, page=<from>-<to> or page=all",
+	"sticker.invalid_media":      "Invalid Media / Link",
+
+	"pack.usage":           "Usage: !pack create <name>, !pack add, !pack list or !pack export [name]",
+	"pack.created":         "✅ Pack \"%s\" created. Reply to a sticker with !pack add to add it",
+	"pack.selected":        "✅ Switched to pack \"%s\" (%d/%d stickers)",
+	"pack.too_many":        "You can have at most %d packs",
+	"pack.none":            "You have no sticker pack yet, create one with !pack create <name>",
+	"pack.not_found":       "Pack \"%s\" not found, see !pack list",
+	"pack.no_sticker":      "Reply to a sticker with !pack add, or send it right after making a sticker",
+	"pack.full":            "Pack \"%s\" is full (%d stickers)",
+	"pack.duplicate":       "That sticker is already in \"%s\"",
+	"pack.added":           "✅ Added to \"%s\" (%d/%d stickers)",
+	"pack.list_title":      "*Your sticker packs*",
+	"pack.list_item":       "- %s (%d/%d)",
+	"pack.current":         "← current",
+	"pack.too_few":         "Pack \"%s\" has %d stickers, at least %d are needed to export it",
+	"pack.invalid_sticker": "That sticker could not be read, so it cannot be added to a pack",
+	"pack.failed":          "⚠️ Failed to process the sticker pack",
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+const FallbackLanguage = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"id": id,
+}
+
+// T returns the message for key in lang, formatted with args. Unknown
+// languages fall back to English and unknown keys are returned as-is.
+func T(lang, key string, args ...any) string {
+	catalog, exists := catalogs[lang]
+	if !exists {
+		catalog = catalogs[FallbackLanguage]
+	}
+
+	message, exists := catalog[key]
+	if !exists {
+		message = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+func IsSupported(lang string) bool {
+	_, exists := catalogs[lang]
+	return exists
+}
+
+func Languages() []string {
+	var languages []string
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// DefaultLanguage is DEFAULT_LANGUAGE from .env, or empty when unset.
+func DefaultLanguage() string {
+	if lang := os.Getenv("DEFAULT_LANGUAGE"); IsSupported(lang) {
+		return lang
+	}
+	return ""
+}
+
+// MissingKeys lists "lang:key" pairs present in one catalog but not in
+// another. The catalog tests check it so an untranslated key fails the
+// build instead of the bot.
+func MissingKeys() []string {
+	var missing []string
+	for lang, catalog := range catalogs {
+		for other, otherCatalog := range catalogs {
+			if other == lang {
+				continue
+			}
+			for key := range otherCatalog {
+				if _, exists := catalog[key]; !exists {
+					missing = append(missing, lang+":"+key)
+				}
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Error is an error whose user-facing text comes from the catalog, so it can
+// be returned from helpers and still be replied in the sender's language.
+type Error struct {
+	Key  string
+	Args []any
+}
+
+func Errorf(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(FallbackLanguage, e.Key, e.Args...)
+}
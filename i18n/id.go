@@ -0,0 +1,240 @@
+package i18n
+
+var id = map[string]string{
+	"common.loading":          "⏳ Loading...",
+	"common.hello":            "Hello, World!",
+	"common.invalid_command":  "Perintah tidak valid",
+	"common.busy":             "Masih ada proses lain, ketik !cancel untuk membatalkan",
+	"common.denied":           "⛔ Anda tidak diizinkan memakai perintah ini di sini",
+	"common.command_disabled": "⛔ Perintah ini dinonaktifkan di grup ini",
+	"common.help_hint":        "!help untuk melihat list command",
+	"common.group_only":       "Perintah ini hanya bisa dipakai di grup",
+	"common.invalid_number":   "Nomor tidak valid",
+
+	"help.title": "*DAFTAR PERINTAH*",
+
+	"help.cat_general": "Umum",
+	"help.cat_sticker": "Stiker",
+	"help.cat_group":   "Grup",
+	"help.cat_admin":   "Admin",
+	"help.cat_owner":   "Owner",
+	"help.cat_user":    "Pengguna",
+
+	"help.help":           "`!help` // Tampilkan daftar perintah ini",
+	"help.reloadacl":      "`!reloadacl` // Muat ulang file kebijakan akses perintah",
+	"help.check":          "`!check` // Cek apakah bot aktif",
+	"help.cancel":         "`!cancel` // Batalkan proses yang sedang berjalan",
+	"help.lang":           "`!lang <id/en>` // Atur bahasamu",
+	"help.group_settings": "`!group settings` // Tampilkan pengaturan grup ini",
+	"help.group_toggle":   "`!group enable` / `!group disable` // Nyalakan/matikan bot di sini",
+	"help.group_command":  "`!group enable <perintah>` / `!group disable <perintah>`",
+	"help.group_lang":     "`!group lang <id/en>` // Bahasa grup",
+	"help.group_prefix":   "`!group prefix <simbol>` // Awalan perintah, bawaan `!`",
+	"help.group_quota":    "`!group quota <N>` // Stiker harian per anggota, 0 = tanpa batas",
+	"help.group_welcome":  "`!group welcome <teks/off>` // Teks sambutan, {user} dan {group} akan diganti",
+	"help.group_allow":    "`!group allow [JID grup]` / `!group deny [JID grup]` // Khusus owner",
+	"help.sticker_url":    "`!sticker <URL video/gif/gambar>` // Dari URL",
+	"help.sticker_media":  "`!sticker` // Kirim bersama, atau balas, gambar/video/gif/stiker",
+	"help.sticker_urls":   "`!sticker <URL> <URL> ...` // Satu stiker per link, maksimal 10",
+	"help.sticker_album":  "`!sticker` sebagai caption album // Satu stiker per item album",
+	"help.take":           "`!take` // Balas stiker untuk menyimpannya dengan nama pack dan author-mu",
+	"help.take_custom":    "`!take <pack>|<author>` // Balas stiker dengan nama pack dan author pilihanmu",
+	"help.pack_create":    "`!pack create <nama>` // Buat pack stiker, atau pindah ke pack yang sudah ada",
+	"help.pack_add":       "`!pack add` // Balas stiker, atau kirim tepat setelah membuatnya, untuk menambahkannya ke pack aktif",
+	"help.pack_list":      "`!pack list` // Pack stiker milikmu",
+	"help.pack_export":    "`!pack export [nama]` // Unduh pack sebagai file .wastickers untuk aplikasi stiker",
+	"help.qc":             "`!qc` // Balas pesan teks untuk menjadikannya stiker bubble chat",
+	"help.qc_text":        "`!qc <teks>` // Stiker bubble chat dari teksmu sendiri",
+	"help.ttp":            "`!ttp <teks>` // Teks jadi stiker",
+	"help.attp":           "`!attp <teks>` // Teks jadi stiker animasi",
+	"help.toimg":          "`!toimg` // Balas stiker untuk menjadikannya gambar",
+	"help.tovid":          "`!tovid` // Balas stiker animasi untuk menjadikannya video",
+	"help.togif":          "`!togif` // Balas stiker animasi untuk menjadikannya GIF",
+	"help.smeme":          "`!smeme <teks atas>|<teks bawah>` // Stiker dengan caption meme, media dan opsinya sama seperti !sticker",
+	"help.smeme_bottom":   "`!smeme |<teks bawah>` // Hanya caption bawah",
+	"help.packname":       "`!packname <teks/reset>` // Nama pack bawaan stikermu",
+	"help.author":         "`!author <teks/reset>` // Author bawaan stikermu",
+	"help.listgroups":     "`!listgroups` // Daftar grup yang diikuti",
+	"help.refreshgroups":  "`!refreshgroups` // Muat ulang cache anggota grup",
+	"help.listmapel":      "`!listmapel`",
+	"help.pdf_number":     "`!pdf <nomor dari !listmapel>`",
+	"help.pdf_name":       "`!pdf <nama mapel>`",
+	"help.answer_number":  "`!answer <nomor dari !listmapel> <jawaban>`",
+	"help.answer_name":    "`!answer <nama mapel> <jawaban>`",
+	"help.gemini":         "`!gemini <nomor/nama mapel>` // Jawab soal dengan Gemini",
+	"help.token":          "`!token`",
+	"help.grant":          "`!grant <nomor> <OWNER/ADMIN/USER/COMMON> [30m/12h/7d/2w]` // Beri role",
+	"help.revoke":         "`!revoke <nomor>` // Cabut role yang diberikan",
+	"help.roles":          "`!roles` // Daftar owner dan role yang diberikan",
+
+	"help.sticker_details": "_Parameter opsional_ (bisa ditambahkan setelah perintah atau URL):\n" +
+		"- `nocrop` // Jangan potong otomatis jadi persegi\n" +
+		"- `start=MM:SS` // Waktu mulai untuk video/gif\n" +
+		"- `end=MM:SS` // Waktu selesai untuk video/gif\n" +
+		"- `fps=N` // Frame per detik (1-60)\n" +
+		"- `quality=N` // Kualitas hasil (1-100)\n" +
+		"- `page=N` // Halaman carousel Instagram, atau rentang seperti `page=1-4` atau `page=all`\n" +
+		"- `direction=side` // Arah geser: up, down, left, right\n" +
+		"- `direction=side-N` // Geser dengan offset (0-50), mis. `right-25`\n" +
+		"- `pack=name` / `author=name` // Metadata stiker, pakai tanda kutip untuk spasi: `pack=\"My Pack\"`\n" +
+		"- `emoji=😂,🔥` // Maksimal 3 tag emoji\n" +
+		"- `shape=circle` // Bentuk: circle, rounded atau square\n" +
+		"- `border=white` // Warna border, nama atau hex seperti `#FF0000`\n" +
+		"- `flip` / `mirror` // Balik atas-bawah / kiri-kanan\n" +
+		"- `rotate=90` // Putar searah jarum jam: 90, 180 atau 270\n" +
+		"- `grayscale` // Hitam putih\n" +
+		"- `bg=remove` // Hapus latar polos, dideteksi dari sudut gambar\n" +
+		"- `bg=remove:white:0.2` // Hapus warna tertentu, dengan toleransi opsional (0.01-1, bawaan 0.15)\n" +
+		"- `speed=2x` // Kecepatan putar video/gif (0.5x-4x)\n" +
+		"- `reverse` / `boomerang` // Putar video/gif mundur / maju lalu mundur\n" +
+		"\n" +
+		"Stiker di atas 1MB otomatis diperkecil; quality, fps dan start/end yang kamu atur sendiri tetap dipakai.\n" +
+		"\n" +
+		"*Contoh:*\n" +
+		"1. !sticker https://demo.alyza.site nocrop start=00:00 end=00:02 fps=24 quality=80\n" +
+		"2. !sticker https://demo.alyza.site/ direction=left-30 quality=90\n" +
+		"3. !sticker shape=circle border=white boomerang",
+
+	"cancel.none":    "❌ Tidak ada proses yang berjalan",
+	"cancel.failed":  "⚠️ Gagal membatalkan proses",
+	"cancel.success": "✅ Proses berhasil dibatalkan",
+
+	"lang.current": "🌐 Bahasa Anda: %s\nGanti dengan !lang <%s>",
+	"lang.set":     "✅ Bahasa diubah ke %s",
+	"lang.usage":   "Format: !lang <%s>",
+	"lang.failed":  "⚠️ Gagal menyimpan bahasa",
+
+	"policy.reload_failed": "⚠️ Gagal memuat ulang policy: %s",
+	"policy.reloaded":      "✅ Policy dimuat ulang",
+
+	"groups.list_title":     "📌 *Daftar Grup:*",
+	"groups.list_item":      "📂 *%s*\n📎 ID: %s",
+	"groups.list_failed":    "⚠️ Gagal mengambil daftar grup",
+	"groups.refresh_failed": "⚠️ Gagal memperbarui cache grup",
+	"groups.refreshed":      "✅ Cache grup diperbarui (%d grup)",
+
+	"mapel.fetch_failed":   "Gagal mengambil daftar mapel.",
+	"mapel.list_item":      "%d. %s",
+	"mapel.invalid_number": "Nomor mapel tidak valid.",
+	"mapel.invalid":        "Mapel tidak valid.",
+	"pdf.bad_format":       "Format perintah salah",
+	"pdf.failed":           "Gagal mengambil PDF",
+	"gemini.upload_failed": "Gagal mengunggah ulang file. Coba lagi",
+
+	"token.ask_name":       "Silakan masukkan nama lengkap Anda.",
+	"token.timeout":        "⏳ Waktu habis! Silakan ketik *!token* lagi.",
+	"token.invalid_name":   "⚠️ Nama Invalid",
+	"token.unknown_number": "⚠️ Nomor Anda tidak dapat dikenali, coba lagi nanti.",
+	"token.failed":         "Gagal mendapatkan token.",
+	"token.new":            "✅ Token baru Anda adalah:",
+	"token.updated":        "Token lama telah tidak berlaku. Ini token baru anda:",
+
+	"role.invalid_role":     "Role tidak valid. Gunakan OWNER, ADMIN, USER atau COMMON",
+	"role.invalid_duration": "Durasi tidak valid. Contoh: 30m, 12h, 7d atau 2w",
+	"role.grant_failed":     "⚠️ Gagal memberikan role",
+	"role.granted":          "✅ %s sekarang %s",
+	"role.granted_until":    "✅ %s sekarang %s sampai %s",
+	"role.revoke_failed":    "⚠️ Gagal mencabut role",
+	"role.not_granted":      "❌ %s tidak memiliki role tambahan",
+	"role.revoked":          "✅ Role %s dicabut",
+	"role.list_failed":      "⚠️ Gagal mengambil daftar role",
+	"role.owners_title":     "👑 *Owner (.env):*",
+	"role.grants_title":     "📌 *Role tambahan:*",
+	"role.until":            " (sampai %s)",
+	"role.owner_item":       "- %s",
+	"role.grant_item":       "- %s: %s",
+	"role.grants_none":      "- (tidak ada)",
+
+	"group.load_failed":   "⚠️ Gagal memuat pengaturan grup",
+	"group.save_failed":   "⚠️ Gagal menyimpan pengaturan grup",
+	"group.admin_only":    "⛔ Hanya admin grup yang bisa mengubah pengaturan grup",
+	"group.owner_only":    "⛔ Hanya owner yang bisa mengizinkan atau menolak grup",
+	"group.enabled":       "✅ Bot diaktifkan di grup ini",
+	"group.disabled":      "✅ Bot dinonaktifkan di grup ini",
+	"group.unknown_cmd":   "Perintah tidak dikenal: %s",
+	"group.cmd_enabled":   "✅ !%s diaktifkan di grup ini",
+	"group.cmd_disabled":  "✅ !%s dinonaktifkan di grup ini",
+	"group.lang_usage":    "Format: !group lang <%s>",
+	"group.lang_set":      "✅ Bahasa grup diubah ke %s",
+	"group.prefix_usage":  "Format: !group prefix <simbol> (maks. 3 simbol, tanpa huruf atau angka)",
+	"group.prefix_set":    "✅ Prefix perintah diubah ke %s",
+	"group.quota_usage":   "Format: !group quota <N> (0 = tanpa batas)",
+	"group.quota_set":     "✅ Kuota stiker harian diubah ke %d",
+	"group.welcome_usage": "Format: !group welcome <teks/off>",
+	"group.welcome_set":   "✅ Teks sambutan diperbarui",
+	"group.invalid_jid":   "JID grup tidak valid, lihat !listgroups",
+	"group.allow_usage":   "Format: !group allow <JID grup>",
+	"group.allowed":       "✅ Grup diizinkan: %s",
+	"group.denied":        "✅ Grup ditolak: %s",
+	"group.settings":      "⚙️ *Pengaturan Grup*\n\nDiizinkan: %s\nBot: %s\nPerintah nonaktif: %s\nBahasa: %s\nPrefix: %s\nKuota stiker: %s\nSambutan: %s",
+	"group.on":            "aktif",
+	"group.off":           "nonaktif",
+	"group.default":       "bawaan",
+	"group.unlimited":     "tanpa batas",
+	"group.quota_per_day": "%d/hari",
+
+	"sticker.quota_reached":      "⛔ Kuota stiker harian habis (%d/%d), coba lagi besok",
+	"sticker.not_under_1mb":      "Gagal membuat stiker di bawah 1MB. Coba salah satu cara berikut:\n- Turunkan kualitas dengan: quality=<0-100>\n- Perpendek durasi video: start=MM:SS end=MM:SS\n- Turunkan FPS video: fps=<1-60>",
+	"sticker.fitted":             "ℹ️ Dikecilkan agar di bawah 1MB: %s",
+	"sticker.fit_quality":        "kualitas %d → %d",
+	"sticker.fit_fps":            "fps %d → %d",
+	"sticker.fit_size":           "detail dikurangi (%dpx → %dpx, tetap tampil 512px)",
+	"sticker.fit_duration":       "durasi %dd → %dd",
+	"sticker.packname":           "Nama pack",
+	"sticker.author":             "Author",
+	"sticker.prefs_current":      "%s: %s",
+	"sticker.prefs_set":          "✅ %s diubah ke %s",
+	"sticker.prefs_reset":        "✅ %s dikembalikan ke bawaan",
+	"sticker.prefs_too_long":     "Teks maksimal %d karakter",
+	"sticker.prefs_failed":       "⚠️ Gagal menyimpan pengaturan stiker",
+	"sticker.too_many_emojis":    "Maksimal 3 emoji",
+	"sticker.take_no_sticker":    "Balas sebuah stiker dengan !take",
+	"sticker.reply_to_sticker":   "Balas sebuah stiker dengan perintah ini",
+	"sticker.not_animated":       "Stiker itu tidak bergerak, gunakan !toimg",
+	"sticker.no_renderable_text": "Teks tidak memiliki karakter yang bisa digambar",
+	"sticker.smeme_usage":        "Format: !smeme <teks atas>|<teks bawah>",
+	"sticker.qc_usage":           "Balas pesan teks dengan !qc, atau kirim !qc <teks>",
+	"sticker.convert_failed":     "Server error: gagal membuat stiker",
+	"sticker.invalid_fps":        "FPS harus antara 1 dan 60",
+	"sticker.invalid_quality":    "Kualitas harus antara 1 dan 100",
+	"sticker.invalid_dir":        "Arah tidak valid. Gunakan up, down, left, atau right (opsional -0 sampai -50)",
+	"sticker.invalid_offset":     "Offset arah harus antara 0 dan 50",
+	"sticker.invalid_shape":      "Bentuk harus circle, rounded, atau square",
+	"sticker.invalid_color":      "Warna tidak dikenal. Gunakan nama seperti white atau kode hex seperti #FF0000",
+	"sticker.invalid_rotate":     "Rotasi harus 90, 180, atau 270",
+	"sticker.invalid_speed":      "Kecepatan harus antara 0.5x dan 4x",
+	"sticker.invalid_bg":         "Opsi latar tidak valid. Gunakan bg=remove, bg=remove:<warna> atau bg=remove:<warna>:<0.01-1>",
+	"sticker.invalid_similarity": "Toleransi latar harus antara 0.01 dan 1",
+	"sticker.end_without_start":  "Waktu akhir diberikan, tetapi waktu mulai tidak",
+	"sticker.invalid_time":       "Format waktu tidak valid. Gunakan MM:SS, contoh start=00:10 end=00:20",
+	"sticker.start_after_end":    "Waktu mulai harus lebih awal dari waktu akhir",
+	"sticker.not_video":          "Bukan video tetapi waktu mulai diberikan",
+	"sticker.start_exceeds":      "Waktu mulai (%.0fd) melebihi durasi media (%.0fd)",
+	"sticker.end_exceeds":        "Waktu akhir (%.0fd) melebihi durasi media (%.0fd)",
+	"sticker.link_unsupported":   "Link tidak didukung",
+	"sticker.no_link":            "Tidak ada link",
+	"sticker.page_exceeded":      "Nomor halaman melebihi jumlah halaman",
+	"sticker.page_not_given":     "Nomor halaman belum diberikan, ketik page=<nomor> atau page=all",
+	"sticker.batch_capped":       "Hanya %d dari %d item pertama yang akan dijadikan stiker",
+	"sticker.batch_progress":     "⏳ Membuat stiker... %d/%d",
+	"sticker.batch_done":         "✅ %d dari %d stiker terkirim",
+	"sticker.invalid_page":       "Halaman tidak valid. Gunakan page=<nomor>, page=<dari>-<sampai> atau page=all",
+	"sticker.invalid_media":      "Media / Link tidak valid",
+
+	"pack.usage":           "Format: !pack create <nama>, !pack add, !pack list atau !pack export [nama]",
+	"pack.created":         "✅ Pack \"%s\" dibuat. Balas stiker dengan !pack add untuk menambahkannya",
+	"pack.selected":        "✅ Beralih ke pack \"%s\" (%d/%d stiker)",
+	"pack.too_many":        "Maksimal %d pack",
+	"pack.none":            "Kamu belum punya pack stiker, buat dengan !pack create <nama>",
+	"pack.not_found":       "Pack \"%s\" tidak ditemukan, lihat !pack list",
+	"pack.no_sticker":      "Balas stiker dengan !pack add, atau kirim tepat setelah membuat stiker",
+	"pack.full":            "Pack \"%s\" sudah penuh (%d stiker)",
+	"pack.duplicate":       "Stiker itu sudah ada di \"%s\"",
+	"pack.added":           "✅ Ditambahkan ke \"%s\" (%d/%d stiker)",
+	"pack.list_title":      "*Pack stiker kamu*",
+	"pack.list_item":       "- %s (%d/%d)",
+	"pack.current":         "← aktif",
+	"pack.too_few":         "Pack \"%s\" berisi %d stiker, minimal %d stiker untuk diekspor",
+	"pack.invalid_sticker": "Stiker itu tidak bisa dibaca, jadi tidak bisa ditambahkan ke pack",
+	"pack.failed":          "⚠️ Gagal memproses pack stiker",
+}
@@ -6,8 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -20,15 +21,21 @@ import (
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 
-	Admin "wa-bot/handlers/adminHandlers"
+	"wa-bot/commands"
+	"wa-bot/dispatcher"
+	_ "wa-bot/handlers/adminHandlers"
 	Common "wa-bot/handlers/commonHandlers"
 	"wa-bot/utils"
 	"wa-bot/state"
+	"wa-bot/storage"
 )
 
 var (
 	dbUrl = "file:wa-bot-session.db?_foreign_keys=on"
 	waClient *whatsmeow.Client
+	// msgDispatcher is read by the HTTP server, which starts before main
+	// creates the dispatcher.
+	msgDispatcher atomic.Pointer[dispatcher.Dispatcher]
 )
 
 func eventHandler(evt any, client *whatsmeow.Client) {
@@ -36,7 +43,7 @@ func eventHandler(evt any, client *whatsmeow.Client) {
 	case *events.Message:
 
 		if v.Info.IsGroup {
-			allowed :=utils.IsFromAllowedGroups(&v.Info)
+			allowed :=utils.IsFromAllowedGroups(&v.Info) || utils.HasOwnerRole(utils.LIDMap.ToPN(v.Info.Sender))
 			if !allowed { return }
 		}
 
@@ -44,100 +51,59 @@ func eventHandler(evt any, client *whatsmeow.Client) {
 		now := time.Now()
 		if now.Sub(msgTime).Seconds() > 10 { return }
 
-		var senderJID types.JID
-		isFromGroup := false
-		if v.Info.IsGroup {
-			senderJID = v.Info.Chat.ToNonAD()
-			isFromGroup = true
-		} else {
-			senderJID = v.Info.Sender.ToNonAD()
-		}
-
-		if senderJID.UserInt() == 13135550002 { return }
-
-		var messageText string
-		if v.Message.ExtendedTextMessage != nil && v.Message.ExtendedTextMessage.Text != nil {
-			messageText = *v.Message.ExtendedTextMessage.Text
-		} else if v.Message.ImageMessage != nil {
-			messageText = *v.Message.ImageMessage.Caption
-		} else if v.Message.VideoMessage != nil {
-			messageText = *v.Message.VideoMessage.Caption
-		} else {
-			messageText = v.Message.GetConversation()
-		}
-
-		message_state := state.NewMessageContext(client, v.Message, senderJID, messageText, isFromGroup)
-
-		fmt.Printf("%s [%s] %d => %s\n",
-			func() string {
-				if message_state.IsFromGroup {
-					return "[Group]"
-				}
-				return ""
-			}(),
-			message_state.UserRole,
-			message_state.SenderJID.UserInt(),
-			message_state.MessageText,
-		)
-
-		if message_state.CheckUserState() != "" {
-			if strings.HasPrefix(messageText, "!cancel") {
-				Common.CancelHandler(message_state)
-				return
-			} else if strings.HasPrefix(messageText, "!") {
-				message_state.Reply("There is another process, !cancel to cancel it")
-				return
-			}
-		}
-
-		stickerRegex := regexp.MustCompile(`^!sticker(\s+\S+)*$`)
-		pdfRegex := regexp.MustCompile(`^!pdf\s+\S+$`)
-		answerPdfRegex := regexp.MustCompile(`^!answer(\s+\S+)*$`)
-		geminiRegex := regexp.MustCompile(`^!gemini(\s+\S+)*$`)
+		inbound := state.NormalizeMessage(v)
 
-		switch {
-		case message_state.MessageText == "!check":
-			Common.CheckHandler(message_state)
+		if inbound.SenderJID.UserInt() == 13135550002 { return }
 
-		case message_state.MessageText == "!listgroups":
-			Admin.ListgroupsHandler(message_state)
+		state.Albums.Track(inbound)
 
-		case message_state.MessageText == "!token":
-			Admin.TokenHandler(message_state)
+		msgDispatcher.Load().Submit(inbound.ChatJID.String(), func() {
+			handleMessage(client, inbound)
+		})
 
-		case message_state.MessageText == "!listmapel":
-			Admin.ListMapelHandler(message_state)
-
-		case pdfRegex.MatchString(message_state.MessageText), answerPdfRegex.MatchString(message_state.MessageText):
-			Admin.SendPDFHandler(message_state)
-
-		case geminiRegex.MatchString(message_state.MessageText):
-			Admin.GeminiHandler(message_state)
+	case *events.Connected:
+		go func() {
+			if err := utils.GroupCache.Load(client); err != nil {
+				fmt.Println("Failed to load group cache:", err)
+			}
+		}()
 
-		case stickerRegex.MatchString(message_state.MessageText):
-			Common.StickerHandler(message_state)
+	case *events.JoinedGroup:
+		utils.GroupCache.Set(&v.GroupInfo)
 
-		case message_state.MessageText == "!help":
-			Common.GetCommandListHandler(message_state)
+	case *events.GroupInfo:
+		utils.GroupCache.ApplyChange(v)
+		go Common.SendWelcome(client, v)
 
-		default:
-			if message_state.CheckUserState() == "PendingToken" {
-				Admin.GetNameHandler(message_state)
-				return
+	case *events.HistorySync:
+		for _, mapping := range v.Data.GetPhoneNumberToLidMappings() {
+			lid, lidErr := types.ParseJID(mapping.GetLidJID())
+			pn, pnErr := types.ParseJID(mapping.GetPnJID())
+			if lidErr == nil && pnErr == nil {
+				utils.LIDMap.Put(lid, pn)
 			}
+		}
+	}
+}
 
-			if strings.HasPrefix(message_state.MessageText, "!") {
-				message_state.Reply("Invalid Command")
-				return
-			}
+// handleMessage runs on the chat's dispatcher worker, off the whatsmeow event
+// goroutine, so slow role resolution only delays messages from the same chat.
+func handleMessage(client *whatsmeow.Client, inbound *state.InboundMessage) {
+	message_state := state.NewMessageContext(client, inbound)
 
-			if message_state.UserRole == "COMMON" {
-				message_state.Reply("!help to see the command list")
-			} else if message_state.UserRole == "USER" {
-				message_state.Reply("!help untuk melihat list command")
+	fmt.Printf("%s [%s] %d => %s\n",
+		func() string {
+			if message_state.IsFromGroup {
+				return "[Group]"
 			}
-		}
-	}
+			return ""
+		}(),
+		message_state.UserRole,
+		message_state.SenderJID.UserInt(),
+		message_state.MessageText,
+	)
+
+	commands.Dispatch(message_state)
 }
 
 func getAuth(client *whatsmeow.Client) {
@@ -203,6 +169,21 @@ func main() {
 		logLevel = "DEBUG"
 	}
 
+	err := storage.Open(dbUrl)
+	if err != nil {
+		panic(err)
+	}
+
+	err = utils.LIDMap.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	err = commands.LoadPolicy()
+	if err != nil {
+		panic(err)
+	}
+
 	dbLog := waLog.Stdout("Database", logLevel, true)
 	container, err := sqlstore.New("sqlite3", dbUrl, dbLog)
 	if err != nil {
@@ -214,6 +195,12 @@ func main() {
 		panic(err)
 	}
 
+	queueSize, err := strconv.Atoi(os.Getenv("DISPATCH_QUEUE_SIZE"))
+	if err != nil {
+		queueSize = 32
+	}
+	msgDispatcher.Store(dispatcher.New(queueSize, time.Minute))
+
 	clientLog := waLog.Stdout("Client", logLevel, true)
 	waClient = whatsmeow.NewClient(deviceStore, clientLog)
 	waClient.AddEventHandler(func(evt any) {
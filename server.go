@@ -51,6 +51,19 @@ func handleSendMessage(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Success"))
 }
 
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	d := msgDispatcher.Load()
+	if d == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Dispatcher not ready"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(d.Stats())
+}
+
 func init() {
 	go func() {
 		r := mux.NewRouter()
@@ -58,6 +71,7 @@ func init() {
 			w.Write([]byte("Hello, World!"))
 		}).Methods("GET")
 		r.HandleFunc("/send-message", handleSendMessage).Methods("POST")
+		r.HandleFunc("/metrics", handleMetrics).Methods("GET")
 
 		fmt.Println("Server running in port 3000")
 		handler := cors.New(cors.Options{
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Albums remembers the items of recently received albums. WhatsApp sends an
+// album as an AlbumMessage announcing the item counts, followed by one
+// message per item linked to it by a MEDIA_ALBUM association, so a command
+// captioned on one item has to collect its siblings from here.
+var Albums = &AlbumStore{data: make(map[string]*album)}
+
+const albumTTL = 2 * time.Minute
+
+type AlbumStore struct {
+	sync.Mutex
+	data map[string]*album
+}
+
+type album struct {
+	Expected int
+	Items    []*InboundMessage
+	Updated  time.Time
+}
+
+func albumKey(in *InboundMessage, albumID string) string {
+	return in.ChatJID.String() + "/" + albumID
+}
+
+// Track records album messages and album items. Other messages are ignored.
+func (a *AlbumStore) Track(in *InboundMessage) {
+	albumMessage := in.Message.GetAlbumMessage()
+	if albumMessage == nil && in.AlbumID == "" {
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	for key, entry := range a.data {
+		if time.Since(entry.Updated) > albumTTL {
+			delete(a.data, key)
+		}
+	}
+
+	key := albumKey(in, in.AlbumID)
+	if albumMessage != nil {
+		key = albumKey(in, in.ID)
+	}
+
+	entry, exists := a.data[key]
+	if !exists {
+		entry = &album{}
+		a.data[key] = entry
+	}
+	entry.Updated = time.Now()
+
+	if albumMessage != nil {
+		entry.Expected = int(albumMessage.GetExpectedImageCount() + albumMessage.GetExpectedVideoCount())
+		return
+	}
+	for _, item := range entry.Items {
+		if item.ID == in.ID {
+			return
+		}
+	}
+	entry.Items = append(entry.Items, in)
+}
+
+// Wait returns the items of the album in is part of, in album order. It waits
+// until every announced item has arrived, or until no new item has arrived
+// for idle when the count is unknown, giving up after timeout.
+func (a *AlbumStore) Wait(ctx context.Context, in *InboundMessage, idle, timeout time.Duration) []*InboundMessage {
+	if in.AlbumID == "" {
+		return nil
+	}
+
+	key := albumKey(in, in.AlbumID)
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		a.Lock()
+		entry := a.data[key]
+		var items []*InboundMessage
+		done := false
+		if entry != nil {
+			items = append(items, entry.Items...)
+			done = (entry.Expected > 0 && len(items) >= entry.Expected) ||
+				(entry.Expected == 0 && time.Since(entry.Updated) >= idle)
+		}
+		a.Unlock()
+
+		if done || time.Now().After(deadline) {
+			sort.SliceStable(items, func(i, j int) bool {
+				if items[i].AlbumIndex != items[j].AlbumIndex {
+					return items[i].AlbumIndex < items[j].AlbumIndex
+				}
+				return items[i].Timestamp.Before(items[j].Timestamp)
+			})
+			return items
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package state
+
+import (
+	"strings"
+	"time"
+
+	"wa-bot/utils"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type MediaKind string
+
+const (
+	MediaNone     MediaKind = ""
+	MediaImage    MediaKind = "image"
+	MediaVideo    MediaKind = "video"
+	MediaGIF      MediaKind = "gif"
+	MediaSticker  MediaKind = "sticker"
+	MediaDocument MediaKind = "document"
+	MediaAudio    MediaKind = "audio"
+)
+
+// InboundMessage is an incoming message with every whatsmeow envelope
+// (device-sent, ephemeral, view-once, document-with-caption, album item,
+// edits) removed.
+type InboundMessage struct {
+	ID          waTypes.MessageID
+	Timestamp   time.Time
+	ChatJID     waTypes.JID
+	SenderJID   waTypes.JID // phone number JID whenever the LID mapping is known
+	SenderLID   waTypes.JID // original @lid sender, empty for phone number senders
+	IsFromGroup bool
+	PushName    string
+
+	Message    *waProto.Message
+	Text       string
+	MediaKind  MediaKind
+	IsEdited   bool
+	IsViewOnce bool
+
+	Quoted       *waProto.Message
+	QuotedID     string
+	QuotedSender waTypes.JID
+	Mentions     []waTypes.JID
+
+	// AlbumID is the ID of the album message this item belongs to, if any.
+	AlbumID    string
+	AlbumIndex int
+}
+
+func NormalizeMessage(evt *events.Message) *InboundMessage {
+	raw := evt.RawMessage
+	if raw == nil {
+		raw = evt.Message
+	}
+
+	msg, isEdited, isViewOnce := UnwrapMessage(raw)
+
+	sender := evt.Info.Sender.ToNonAD()
+	var senderLID waTypes.JID
+	if sender.Server == waTypes.HiddenUserServer {
+		senderLID = sender
+	}
+
+	in := &InboundMessage{
+		ID:          evt.Info.ID,
+		Timestamp:   evt.Info.Timestamp,
+		ChatJID:     evt.Info.Chat.ToNonAD(),
+		SenderJID:   utils.LIDMap.ToPN(sender),
+		SenderLID:   senderLID,
+		IsFromGroup: evt.Info.IsGroup,
+		PushName:    evt.Info.PushName,
+		Message:     msg,
+		Text:        ExtractText(msg),
+		MediaKind:   GetMediaKind(msg),
+		IsEdited:    isEdited || evt.IsEdit,
+		IsViewOnce:  isViewOnce || evt.IsViewOnce,
+	}
+
+	if ctxInfo := GetContextInfo(msg); ctxInfo != nil {
+		in.Quoted = ctxInfo.GetQuotedMessage()
+		if in.Quoted != nil {
+			in.Quoted, _, _ = UnwrapMessage(in.Quoted)
+		}
+		in.QuotedID = ctxInfo.GetStanzaID()
+		if participant, err := waTypes.ParseJID(ctxInfo.GetParticipant()); err == nil {
+			in.QuotedSender = utils.LIDMap.ToPN(participant)
+		}
+		for _, mentioned := range ctxInfo.GetMentionedJID() {
+			if jid, err := waTypes.ParseJID(mentioned); err == nil {
+				in.Mentions = append(in.Mentions, jid)
+			}
+		}
+	}
+
+	if association := albumAssociation(raw, msg); association != nil {
+		in.AlbumID = association.GetParentMessageKey().GetID()
+		in.AlbumIndex = int(association.GetMessageIndex())
+	}
+
+	return in
+}
+
+// albumAssociation finds the MEDIA_ALBUM association of an album item, which
+// sits either on the outer message or on the one wrapped inside it.
+func albumAssociation(raw, msg *waProto.Message) *waProto.MessageAssociation {
+	for _, m := range []*waProto.Message{raw, raw.GetAssociatedChildMessage().GetMessage(), msg} {
+		association := m.GetMessageContextInfo().GetMessageAssociation()
+		if association.GetAssociationType() == waProto.MessageAssociation_MEDIA_ALBUM &&
+			association.GetParentMessageKey().GetID() != "" {
+			return association
+		}
+	}
+	return nil
+}
+
+// UnwrapMessage peels off wrapper messages until the innermost content is
+// reached. It is safe to call on an already unwrapped message.
+func UnwrapMessage(msg *waProto.Message) (*waProto.Message, bool, bool) {
+	isEdited, isViewOnce := false, false
+
+	for msg != nil {
+		switch {
+		case msg.GetDeviceSentMessage().GetMessage() != nil:
+			msg = msg.GetDeviceSentMessage().GetMessage()
+		case msg.GetEphemeralMessage().GetMessage() != nil:
+			msg = msg.GetEphemeralMessage().GetMessage()
+		case msg.GetViewOnceMessage().GetMessage() != nil:
+			msg = msg.GetViewOnceMessage().GetMessage()
+			isViewOnce = true
+		case msg.GetViewOnceMessageV2().GetMessage() != nil:
+			msg = msg.GetViewOnceMessageV2().GetMessage()
+			isViewOnce = true
+		case msg.GetViewOnceMessageV2Extension().GetMessage() != nil:
+			msg = msg.GetViewOnceMessageV2Extension().GetMessage()
+			isViewOnce = true
+		case msg.GetDocumentWithCaptionMessage().GetMessage() != nil:
+			msg = msg.GetDocumentWithCaptionMessage().GetMessage()
+		case msg.GetAssociatedChildMessage().GetMessage() != nil:
+			msg = msg.GetAssociatedChildMessage().GetMessage()
+		case msg.GetEditedMessage().GetMessage() != nil:
+			msg = msg.GetEditedMessage().GetMessage()
+			isEdited = true
+		case msg.GetProtocolMessage().GetType() == waProto.ProtocolMessage_MESSAGE_EDIT &&
+			msg.GetProtocolMessage().GetEditedMessage() != nil:
+			msg = msg.GetProtocolMessage().GetEditedMessage()
+			isEdited = true
+		default:
+			return msg, isEdited, isViewOnce
+		}
+	}
+
+	return msg, isEdited, isViewOnce
+}
+
+// ExtractText returns the user-visible text of a message: the body, the
+// caption of a media message or the selection of a button/list reply.
+func ExtractText(msg *waProto.Message) string {
+	switch {
+	case msg == nil:
+		return ""
+	case msg.GetConversation() != "":
+		return msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	case msg.GetButtonsResponseMessage() != nil:
+		return firstNonEmpty(
+			msg.GetButtonsResponseMessage().GetSelectedButtonID(),
+			msg.GetButtonsResponseMessage().GetSelectedDisplayText(),
+		)
+	case msg.GetTemplateButtonReplyMessage() != nil:
+		return firstNonEmpty(
+			msg.GetTemplateButtonReplyMessage().GetSelectedID(),
+			msg.GetTemplateButtonReplyMessage().GetSelectedDisplayText(),
+		)
+	case msg.GetListResponseMessage() != nil:
+		return firstNonEmpty(
+			msg.GetListResponseMessage().GetSingleSelectReply().GetSelectedRowID(),
+			msg.GetListResponseMessage().GetTitle(),
+		)
+	case msg.GetInteractiveResponseMessage() != nil:
+		return msg.GetInteractiveResponseMessage().GetBody().GetText()
+	}
+
+	return ""
+}
+
+func GetMediaKind(msg *waProto.Message) MediaKind {
+	switch {
+	case msg == nil:
+		return MediaNone
+	case msg.GetVideoMessage() != nil:
+		if msg.GetVideoMessage().GetGifPlayback() {
+			return MediaGIF
+		}
+		return MediaVideo
+	case msg.GetImageMessage() != nil:
+		return MediaImage
+	case msg.GetStickerMessage() != nil:
+		return MediaSticker
+	case msg.GetDocumentMessage() != nil:
+		return MediaDocument
+	case msg.GetAudioMessage() != nil:
+		return MediaAudio
+	}
+
+	return MediaNone
+}
+
+func GetContextInfo(msg *waProto.Message) *waProto.ContextInfo {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetContextInfo()
+	case msg.GetButtonsResponseMessage() != nil:
+		return msg.GetButtonsResponseMessage().GetContextInfo()
+	case msg.GetTemplateButtonReplyMessage() != nil:
+		return msg.GetTemplateButtonReplyMessage().GetContextInfo()
+	case msg.GetListResponseMessage() != nil:
+		return msg.GetListResponseMessage().GetContextInfo()
+	case msg.GetInteractiveResponseMessage() != nil:
+		return msg.GetInteractiveResponseMessage().GetContextInfo()
+	}
+
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package state
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	textFixture = &waProto.Message{Conversation: proto.String("!sticker")}
+
+	imageFixture = &waProto.Message{ImageMessage: &waProto.ImageMessage{
+		Caption:  proto.String("!sticker nocrop"),
+		Mimetype: proto.String("image/jpeg"),
+	}}
+
+	documentFixture = &waProto.Message{DocumentMessage: &waProto.DocumentMessage{
+		Caption:  proto.String("!topdf"),
+		Mimetype: proto.String("image/png"),
+		FileName: proto.String("scan.png"),
+	}}
+
+	quotedFixture = &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+		Text: proto.String("!toimg"),
+		ContextInfo: &waProto.ContextInfo{
+			StanzaID:    proto.String("QUOTED"),
+			Participant: proto.String("6281200000002@s.whatsapp.net"),
+			QuotedMessage: &waProto.Message{EphemeralMessage: &waProto.FutureProofMessage{
+				Message: &waProto.Message{StickerMessage: &waProto.StickerMessage{}},
+			}},
+			MentionedJID: []string{"6281200000003@s.whatsapp.net"},
+		},
+	}}
+)
+
+func ephemeral(msg *waProto.Message) *waProto.Message {
+	return &waProto.Message{EphemeralMessage: &waProto.FutureProofMessage{Message: msg}}
+}
+
+func viewOnce(msg *waProto.Message) *waProto.Message {
+	return &waProto.Message{ViewOnceMessageV2: &waProto.FutureProofMessage{Message: msg}}
+}
+
+func edited(msg *waProto.Message) *waProto.Message {
+	return &waProto.Message{ProtocolMessage: &waProto.ProtocolMessage{
+		Type:          waProto.ProtocolMessage_MESSAGE_EDIT.Enum(),
+		EditedMessage: msg,
+	}}
+}
+
+func documentWithCaption(msg *waProto.Message) *waProto.Message {
+	return &waProto.Message{DocumentWithCaptionMessage: &waProto.FutureProofMessage{Message: msg}}
+}
+
+func TestUnwrapMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          *waProto.Message
+		want         *waProto.Message
+		wantEdited   bool
+		wantViewOnce bool
+	}{
+		{"plain", textFixture, textFixture, false, false},
+		{"ephemeral", ephemeral(textFixture), textFixture, false, false},
+		{"view once", viewOnce(imageFixture), imageFixture, false, true},
+		{"ephemeral view once", ephemeral(viewOnce(imageFixture)), imageFixture, false, true},
+		{"edited", edited(textFixture), textFixture, true, false},
+		{"edited in ephemeral chat", ephemeral(edited(textFixture)), textFixture, true, false},
+		{"document with caption", documentWithCaption(documentFixture), documentFixture, false, false},
+		{"quoted", quotedFixture, quotedFixture, false, false},
+		{"nil", nil, nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isEdited, isViewOnce := UnwrapMessage(tt.msg)
+			if got != tt.want {
+				t.Errorf("unwrapped to %v, want %v", got, tt.want)
+			}
+			if isEdited != tt.wantEdited || isViewOnce != tt.wantViewOnce {
+				t.Errorf("edited=%v view once=%v, want %v %v", isEdited, isViewOnce, tt.wantEdited, tt.wantViewOnce)
+			}
+
+			// Unwrapping again changes nothing.
+			if again, _, _ := UnwrapMessage(got); again != got {
+				t.Errorf("second unwrap returned %v", again)
+			}
+		})
+	}
+}
+
+func TestExtractTextAndMediaKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      *waProto.Message
+		wantText string
+		wantKind MediaKind
+	}{
+		{"conversation", textFixture, "!sticker", MediaNone},
+		{"image caption", imageFixture, "!sticker nocrop", MediaImage},
+		{"document caption", documentFixture, "!topdf", MediaDocument},
+		{"quoted reply", quotedFixture, "!toimg", MediaNone},
+		{"gif", &waProto.Message{VideoMessage: &waProto.VideoMessage{GifPlayback: proto.Bool(true)}}, "", MediaGIF},
+		{"video", &waProto.Message{VideoMessage: &waProto.VideoMessage{Caption: proto.String("!sticker")}}, "!sticker", MediaVideo},
+		{"sticker", &waProto.Message{StickerMessage: &waProto.StickerMessage{}}, "", MediaSticker},
+		{"audio", &waProto.Message{AudioMessage: &waProto.AudioMessage{}}, "", MediaAudio},
+		{"button reply", &waProto.Message{ButtonsResponseMessage: &waProto.ButtonsResponseMessage{
+			SelectedButtonID: proto.String("!menu"),
+		}}, "!menu", MediaNone},
+		{"list reply", &waProto.Message{ListResponseMessage: &waProto.ListResponseMessage{
+			Title:             proto.String("Menu"),
+			SingleSelectReply: &waProto.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("!help")},
+		}}, "!help", MediaNone},
+		{"nil", nil, "", MediaNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractText(tt.msg); got != tt.wantText {
+				t.Errorf("text %q, want %q", got, tt.wantText)
+			}
+			if got := GetMediaKind(tt.msg); got != tt.wantKind {
+				t.Errorf("media kind %q, want %q", got, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	group := waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	sender := waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+
+	tests := []struct {
+		name         string
+		msg          *waProto.Message
+		isEdit       bool
+		wantText     string
+		wantKind     MediaKind
+		wantEdited   bool
+		wantViewOnce bool
+	}{
+		{"ephemeral", ephemeral(textFixture), false, "!sticker", MediaNone, false, false},
+		{"view once", viewOnce(imageFixture), false, "!sticker nocrop", MediaImage, false, true},
+		{"edited", edited(textFixture), false, "!sticker", MediaNone, true, false},
+		{"edit flagged by whatsmeow", textFixture, true, "!sticker", MediaNone, true, false},
+		{"document with caption", ephemeral(documentWithCaption(documentFixture)), false, "!topdf", MediaDocument, false, false},
+		{"quoted", ephemeral(quotedFixture), false, "!toimg", MediaNone, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := &events.Message{
+				Info: waTypes.MessageInfo{
+					MessageSource: waTypes.MessageSource{Chat: group, Sender: sender, IsGroup: true},
+					ID:            "ID",
+					PushName:      "Alice",
+				},
+				RawMessage: tt.msg,
+				IsEdit:     tt.isEdit,
+			}
+
+			in := NormalizeMessage(evt)
+			if in.Text != tt.wantText || in.MediaKind != tt.wantKind {
+				t.Errorf("got %q %q, want %q %q", in.Text, in.MediaKind, tt.wantText, tt.wantKind)
+			}
+			if in.IsEdited != tt.wantEdited || in.IsViewOnce != tt.wantViewOnce {
+				t.Errorf("edited=%v view once=%v, want %v %v", in.IsEdited, in.IsViewOnce, tt.wantEdited, tt.wantViewOnce)
+			}
+			if in.ChatJID != group || in.SenderJID != sender || !in.IsFromGroup || in.PushName != "Alice" {
+				t.Errorf("envelope %v %v %v %q", in.ChatJID, in.SenderJID, in.IsFromGroup, in.PushName)
+			}
+		})
+	}
+}
+
+func TestNormalizeMessageQuoted(t *testing.T) {
+	evt := &events.Message{
+		Info: waTypes.MessageInfo{MessageSource: waTypes.MessageSource{
+			Chat:   waTypes.NewJID("6281200000001", waTypes.DefaultUserServer),
+			Sender: waTypes.NewJID("6281200000001", waTypes.DefaultUserServer),
+		}},
+		RawMessage: ephemeral(quotedFixture),
+	}
+
+	in := NormalizeMessage(evt)
+	if in.QuotedID != "QUOTED" || in.QuotedSender.User != "6281200000002" {
+		t.Errorf("quoted %q from %v", in.QuotedID, in.QuotedSender)
+	}
+	if GetMediaKind(in.Quoted) != MediaSticker {
+		t.Errorf("quoted message was not unwrapped: %v", in.Quoted)
+	}
+	if len(in.Mentions) != 1 || in.Mentions[0].User != "6281200000003" {
+		t.Errorf("mentions %v", in.Mentions)
+	}
+}
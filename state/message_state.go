@@ -4,7 +4,10 @@ import (
 	context "context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"wa-bot/i18n"
+	"wa-bot/storage"
 	"wa-bot/utils"
 
 	"go.mau.fi/whatsmeow"
@@ -13,30 +16,153 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// MessageState carries a single incoming message. Replies always go to
+// ChatJID, while roles and process state are tracked per SenderJID, so in a
+// group ChatJID is the group and SenderJID is the participant who wrote it.
 type MessageState struct {
 	Client      *whatsmeow.Client
+	Messenger   Messenger
+	Inbound     *InboundMessage
 	VMessage    *waProto.Message
+	ChatJID     waTypes.JID
 	SenderJID   waTypes.JID
+	SenderLID   waTypes.JID
 	MessageText string
 	IsFromGroup bool
 	UserRole    string
+	Language    string
 }
 
-func NewMessageContext(client *whatsmeow.Client, vMessage *waProto.Message, senderJID waTypes.JID, messageText string, isFromGroup bool) *MessageState {
-	return &MessageState{
+func NewMessageContext(client *whatsmeow.Client, in *InboundMessage) *MessageState {
+	s := &MessageState{
 		Client:      client,
-		VMessage:    vMessage,
-		SenderJID:   senderJID,
-		MessageText: messageText,
-		IsFromGroup: isFromGroup,
-		UserRole:    utils.AssignRole(client, isFromGroup, senderJID),
+		Messenger:   ClientMessenger{client},
+		Inbound:     in,
+		VMessage:    in.Message,
+		ChatJID:     in.ChatJID,
+		SenderJID:   in.SenderJID,
+		SenderLID:   in.SenderLID,
+		MessageText: in.Text,
+		IsFromGroup: in.IsFromGroup,
+		UserRole:    utils.AssignRole(client, in.IsFromGroup, in.ChatJID, in.SenderJID),
 	}
+	s.Language = s.resolveLanguage()
+	return s
+}
+
+// resolveLanguage picks the reply language: the sender's own !lang choice,
+// then the group's language, then DEFAULT_LANGUAGE. Without any of those,
+// members of the configured groups get Indonesian and everyone else English.
+func (s *MessageState) resolveLanguage() string {
+	if lang, err := storage.GetUserLanguage(s.SenderJID.String()); err == nil && i18n.IsSupported(lang) {
+		return lang
+	}
+	if s.IsFromGroup {
+		if settings, err := storage.GetGroupSettings(s.ChatJID.String()); err == nil && i18n.IsSupported(settings.Language) {
+			return settings.Language
+		}
+	}
+	if lang := i18n.DefaultLanguage(); lang != "" {
+		return lang
+	}
+	if s.UserRole == "COMMON" {
+		return "en"
+	}
+	return "id"
 }
 
 func (s *MessageState) Reply(text string) {
-	s.Client.SendMessage(context.Background(), s.SenderJID, &waProto.Message{
-		Conversation: proto.String(text),
-	})
+	s.Messenger.SendMessage(context.Background(), s.ChatJID, s.textMessage(text))
+}
+
+// textMessage builds a text reply, quoting the command in groups.
+func (s *MessageState) textMessage(text string) *waProto.Message {
+	if ctxInfo := s.replyContext(); ctxInfo != nil {
+		return &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: ctxInfo,
+		}}
+	}
+	return &waProto.Message{Conversation: proto.String(text)}
+}
+
+// replyContext quotes the command in groups, so that when several
+// participants run jobs at once each reply shows whose it is. The quoted
+// participant must be the address the sender wrote from, or WhatsApp cannot
+// resolve the quote in LID-addressed groups.
+func (s *MessageState) replyContext() *waProto.ContextInfo {
+	if !s.IsFromGroup || s.Inbound == nil || s.Inbound.ID == "" {
+		return nil
+	}
+	return &waProto.ContextInfo{
+		StanzaID:      proto.String(s.Inbound.ID),
+		Participant:   proto.String(s.SenderAddress().String()),
+		QuotedMessage: s.Inbound.Message,
+	}
+}
+
+// ReplyEditable replies with text and returns a function that edits that
+// reply in place, for progress updates. If the reply cannot be sent, updates
+// are sent as new messages instead.
+func (s *MessageState) ReplyEditable(text string) func(string) {
+	resp, err := s.Messenger.SendMessage(context.Background(), s.ChatJID, s.textMessage(text))
+	if err != nil {
+		return s.Reply
+	}
+
+	return func(newText string) {
+		s.Messenger.SendMessage(context.Background(), s.ChatJID, s.Messenger.BuildEdit(s.ChatJID, resp.ID, &waProto.Message{
+			Conversation: proto.String(newText),
+		}))
+	}
+}
+
+// T looks key up in the catalog of the sender's language.
+func (s *MessageState) T(key string, args ...any) string {
+	return i18n.T(s.Language, key, args...)
+}
+
+func (s *MessageState) ReplyT(key string, args ...any) {
+	s.Reply(s.T(key, args...))
+}
+
+// ReplyErr replies with a catalog error in the sender's language, or with the
+// raw error text for any other error.
+func (s *MessageState) ReplyErr(err error) {
+	var catalogErr *i18n.Error
+	if errors.As(err, &catalogErr) {
+		s.ReplyT(catalogErr.Key, catalogErr.Args...)
+		return
+	}
+	s.Reply(err.Error())
+}
+
+// SenderAddress returns the JID the sender wrote from, which is the LID in
+// LID-addressed groups. Use it when mentioning or quoting the sender.
+func (s *MessageState) SenderAddress() waTypes.JID {
+	if !s.SenderLID.IsEmpty() {
+		return s.SenderLID
+	}
+	return s.SenderJID
+}
+
+// ResolveUserJID turns a command argument into a phone number JID. Mentions
+// ("@123...") are matched against the message's mentioned JIDs first so that
+// mentioned LIDs are mapped back to phone numbers.
+func (s *MessageState) ResolveUserJID(arg string) (waTypes.JID, error) {
+	if strings.HasPrefix(arg, "@") && s.Inbound != nil {
+		for _, mentioned := range s.Inbound.Mentions {
+			if mentioned.User == strings.TrimPrefix(arg, "@") {
+				return utils.LIDMap.ToPN(mentioned), nil
+			}
+		}
+	}
+
+	jid, err := utils.ParseUserJID(arg)
+	if err != nil {
+		return jid, err
+	}
+	return utils.LIDMap.ToPN(jid), nil
 }
 
 func (s *MessageState) UploadToWhatsapp(ctx context.Context, filedata []byte, dataType string) (*whatsmeow.UploadResponse, error) {
@@ -44,48 +170,125 @@ func (s *MessageState) UploadToWhatsapp(ctx context.Context, filedata []byte, da
 	switch dataType {
 	case "image":
 		mediaType = whatsmeow.MediaImage
+	case "video":
+		mediaType = whatsmeow.MediaVideo
 	default:
 		mediaType = whatsmeow.MediaDocument
 	}
 
-	uploaded, err := s.Client.Upload(ctx, filedata, mediaType)
+	uploaded, err := s.Messenger.Upload(ctx, filedata, mediaType)
 	return &uploaded, err
 }
 
-func (s *MessageState) SendDocumentMessage(ctx context.Context, uploadedData *whatsmeow.UploadResponse, documentTitle string) error {
-	_, err := s.Client.SendMessage(ctx, s.SenderJID, &waProto.Message{
+// SendDocumentMessage sends an uploaded file as a document. The file name is
+// also its title and decides which app opens it on the recipient's phone.
+func (s *MessageState) SendDocumentMessage(ctx context.Context, uploadedData *whatsmeow.UploadResponse, fileName string, mimetype string) error {
+	_, err := s.Messenger.SendMessage(ctx, s.ChatJID, &waProto.Message{
 		DocumentMessage: &waProto.DocumentMessage{
-			Title:         proto.String(documentTitle),
-			Mimetype:      proto.String("application/pdf"),
+			Title:         proto.String(fileName),
+			FileName:      proto.String(fileName),
+			Mimetype:      proto.String(mimetype),
 			URL:           proto.String(uploadedData.URL),
 			DirectPath:    proto.String(uploadedData.DirectPath),
 			MediaKey:      uploadedData.MediaKey,
 			FileEncSHA256: uploadedData.FileEncSHA256,
 			FileSHA256:    uploadedData.FileSHA256,
 			FileLength:    proto.Uint64(uploadedData.FileLength),
+			ContextInfo:   s.replyContext(),
 		},
 	})
 	return err
 }
 
-func (s *MessageState) GetDownloadableMedia() ([]byte, bool, error) {
-	var downloadableMedia whatsmeow.DownloadableMessage
-	var isAnimated bool
+// QuotedMessage returns the message this one replies to, if any.
+func (s *MessageState) QuotedMessage() *waProto.Message {
+	if s.Inbound == nil {
+		return nil
+	}
+	return s.Inbound.Quoted
+}
+
+// findMedia picks the image, video, GIF, sticker or media document carried by
+// msg, and whether it should be treated as animated.
+func findMedia(msg *waProto.Message) (whatsmeow.DownloadableMessage, bool) {
+	if msg == nil {
+		return nil, false
+	}
+
+	switch {
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage(), true
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage(), false
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage(), msg.GetStickerMessage().GetIsAnimated()
+	case msg.GetDocumentMessage() != nil:
+		mime := msg.GetDocumentMessage().GetMimetype()
+		if strings.HasPrefix(mime, "video/") || mime == "image/gif" {
+			return msg.GetDocumentMessage(), true
+		} else if strings.HasPrefix(mime, "image/") {
+			return msg.GetDocumentMessage(), false
+		}
+	}
 
-	if s.VMessage.GetVideoMessage() != nil {
-		downloadableMedia = s.VMessage.GetVideoMessage()
-		isAnimated = true
+	return nil, false
+}
+
+// downloadableMedia resolves media from the message itself first, then from
+// the message it quotes.
+func (s *MessageState) downloadableMedia() (whatsmeow.DownloadableMessage, bool) {
+	if media, isAnimated := findMedia(s.VMessage); media != nil {
+		return media, isAnimated
+	}
+	return findMedia(s.QuotedMessage())
+}
+
+func (s *MessageState) HasDownloadableMedia() bool {
+	media, _ := s.downloadableMedia()
+	return media != nil
+}
 
-	} else if s.VMessage.GetImageMessage() != nil {
-		downloadableMedia = s.VMessage.GetImageMessage()
-		isAnimated = false
+// MediaSHA256 returns the FileSHA256 WhatsApp sent for the media of msg, or
+// of the message itself when msg is nil, so it can be looked up in a cache
+// before downloading.
+func (s *MessageState) MediaSHA256(msg *waProto.Message) ([]byte, bool) {
+	var media whatsmeow.DownloadableMessage
+	var isAnimated bool
+	if msg == nil {
+		media, isAnimated = s.downloadableMedia()
+	} else {
+		media, isAnimated = findMedia(msg)
+	}
+	if media == nil {
+		return nil, false
 	}
+	return media.GetFileSHA256(), isAnimated
+}
+
+func (s *MessageState) GetDownloadableMedia() ([]byte, bool, error) {
+	downloadableMedia, isAnimated := s.downloadableMedia()
 
 	if downloadableMedia == nil {
 		return nil, isAnimated, fmt.Errorf("no downloadable media found")
 	}
 
-	data, err := s.Client.Download(downloadableMedia)
+	data, err := s.Messenger.Download(downloadableMedia)
+	if err != nil {
+		return nil, isAnimated, fmt.Errorf("download failed: %w", err)
+	}
+
+	return data, isAnimated, nil
+}
+
+// DownloadMessageMedia downloads the media of another message, such as one
+// item of an album.
+func (s *MessageState) DownloadMessageMedia(msg *waProto.Message) ([]byte, bool, error) {
+	media, isAnimated := findMedia(msg)
+	if media == nil {
+		return nil, isAnimated, fmt.Errorf("no downloadable media found")
+	}
+
+	data, err := s.Messenger.Download(media)
 	if err != nil {
 		return nil, isAnimated, fmt.Errorf("download failed: %w", err)
 	}
@@ -94,7 +297,7 @@ func (s *MessageState) GetDownloadableMedia() ([]byte, bool, error) {
 }
 
 func (s *MessageState) SendStickerMessage(ctx context.Context, uploadedData *whatsmeow.UploadResponse, isAnimated bool) error {
-	_, err := s.Client.SendMessage(ctx, s.SenderJID, &waProto.Message{
+	_, err := s.Messenger.SendMessage(ctx, s.ChatJID, &waProto.Message{
 		StickerMessage: &waProto.StickerMessage{
 			Mimetype:      proto.String("image/webp"),
 			URL:           proto.String(uploadedData.URL),
@@ -104,6 +307,45 @@ func (s *MessageState) SendStickerMessage(ctx context.Context, uploadedData *wha
 			FileSHA256:    uploadedData.FileSHA256,
 			FileLength:    proto.Uint64(uploadedData.FileLength),
 			IsAnimated:    proto.Bool(isAnimated),
+			ContextInfo:   s.replyContext(),
+		},
+	})
+
+	return err
+}
+
+func (s *MessageState) SendImageMessage(ctx context.Context, uploadedData *whatsmeow.UploadResponse, mimetype string) error {
+	_, err := s.Messenger.SendMessage(ctx, s.ChatJID, &waProto.Message{
+		ImageMessage: &waProto.ImageMessage{
+			Mimetype:      proto.String(mimetype),
+			URL:           proto.String(uploadedData.URL),
+			DirectPath:    proto.String(uploadedData.DirectPath),
+			MediaKey:      uploadedData.MediaKey,
+			FileEncSHA256: uploadedData.FileEncSHA256,
+			FileSHA256:    uploadedData.FileSHA256,
+			FileLength:    proto.Uint64(uploadedData.FileLength),
+			ContextInfo:   s.replyContext(),
+		},
+	})
+
+	return err
+}
+
+// SendVideoMessage sends an MP4. With gifPlayback set WhatsApp shows it as a
+// looping, muted GIF.
+func (s *MessageState) SendVideoMessage(ctx context.Context, uploadedData *whatsmeow.UploadResponse, seconds int, gifPlayback bool) error {
+	_, err := s.Messenger.SendMessage(ctx, s.ChatJID, &waProto.Message{
+		VideoMessage: &waProto.VideoMessage{
+			Mimetype:      proto.String("video/mp4"),
+			URL:           proto.String(uploadedData.URL),
+			DirectPath:    proto.String(uploadedData.DirectPath),
+			MediaKey:      uploadedData.MediaKey,
+			FileEncSHA256: uploadedData.FileEncSHA256,
+			FileSHA256:    uploadedData.FileSHA256,
+			FileLength:    proto.Uint64(uploadedData.FileLength),
+			Seconds:       proto.Uint32(uint32(seconds)),
+			GifPlayback:   proto.Bool(gifPlayback),
+			ContextInfo:   s.replyContext(),
 		},
 	})
 
@@ -118,16 +360,23 @@ func (s *MessageState) ReplyNoCancelError(ctx context.Context, err error, msg st
 	}
 }
 
+// processKey identifies the sender's process. It is the participant's phone
+// number JID, so participants of a group each have their own while one
+// participant has a single process across all chats.
+func (s *MessageState) processKey() string {
+	return utils.LIDMap.ToPN(s.SenderJID).String()
+}
+
 func (s *MessageState) AddUserToState(status string, cancel func()) {
-	UserState.AddUser(s.SenderJID.String(), status, cancel)
+	UserState.AddUser(s.processKey(), status, cancel)
 }
 
 func (s *MessageState) ClearUserState() {
-	UserState.ClearUser(s.SenderJID.String())
+	UserState.ClearUser(s.processKey())
 }
 
 func (s *MessageState) CheckUserState() string {
-	data, exists := UserState.GetUserStatus(s.SenderJID.String())
+	data, exists := UserState.GetUserStatus(s.processKey())
 	if !exists {
 		return ""
 	}
@@ -135,7 +384,7 @@ func (s *MessageState) CheckUserState() string {
 }
 
 func (s *MessageState) GetUserPendingStartTime() (time.Time, error) {
-	data, exists := UserState.GetUserStatus(s.SenderJID.String())
+	data, exists := UserState.GetUserStatus(s.processKey())
 	if !exists {
 		return data.StartTime, fmt.Errorf("user not found in state")
 	}
@@ -144,9 +393,9 @@ func (s *MessageState) GetUserPendingStartTime() (time.Time, error) {
 }
 
 func (s *MessageState) UpdateUserProcess(cancel func()) {
-	UserState.UpdateProcessContext(s.SenderJID.String(), cancel)
+	UserState.UpdateProcessContext(s.processKey(), cancel)
 }
 
 func (s *MessageState) CancelCurrentProcess() error {
-	return UserState.CancelUser(s.SenderJID.String())
+	return UserState.CancelUser(s.processKey())
 }
@@ -0,0 +1,151 @@
+package state
+
+import (
+	"testing"
+
+	"wa-bot/state/statetest"
+	"wa-bot/utils"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	mixedGroup = waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	alicePN    = waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+	aliceLID   = waTypes.NewJID("100000000000001", waTypes.HiddenUserServer)
+	bobPN      = waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+	carolLID   = waTypes.NewJID("100000000000003", waTypes.HiddenUserServer) // mapping unknown
+)
+
+// withLIDMapping makes lid known as pn for the duration of the test, without
+// touching the database.
+func withLIDMapping(t *testing.T, lid, pn waTypes.JID) {
+	t.Helper()
+	utils.LIDMap.Lock()
+	utils.LIDMap.LIDToPN[lid.String()] = pn
+	utils.LIDMap.PNToLID[pn.String()] = lid
+	utils.LIDMap.Unlock()
+
+	t.Cleanup(func() {
+		utils.LIDMap.Lock()
+		delete(utils.LIDMap.LIDToPN, lid.String())
+		delete(utils.LIDMap.PNToLID, pn.String())
+		utils.LIDMap.Unlock()
+	})
+}
+
+// groupMessage normalizes a text message as whatsmeow would deliver it from
+// sender, which carries a device part like real events.
+func groupMessage(sender waTypes.JID, text string, mentions ...string) *InboundMessage {
+	sender.Device = 3
+	msg := &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+		Text:        proto.String(text),
+		ContextInfo: &waProto.ContextInfo{MentionedJID: mentions},
+	}}
+	return NormalizeMessage(&events.Message{
+		Info: waTypes.MessageInfo{
+			MessageSource: waTypes.MessageSource{Chat: mixedGroup, Sender: sender, IsGroup: true},
+			ID:            waTypes.MessageID("ID-" + sender.User),
+		},
+		RawMessage: msg,
+	})
+}
+
+func TestMixedGroupSenders(t *testing.T) {
+	withLIDMapping(t, aliceLID, alicePN)
+
+	tests := []struct {
+		name        string
+		sender      waTypes.JID
+		wantJID     waTypes.JID
+		wantLID     waTypes.JID
+		wantAddress waTypes.JID
+	}{
+		{"mapped LID", aliceLID, alicePN, aliceLID, aliceLID},
+		{"phone number", bobPN, bobPN, waTypes.EmptyJID, bobPN},
+		{"unmapped LID", carolLID, carolLID, carolLID, carolLID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := groupMessage(tt.sender, "!check")
+			if in.SenderJID != tt.wantJID || in.SenderLID != tt.wantLID {
+				t.Fatalf("sender %v lid %v, want %v %v", in.SenderJID, in.SenderLID, tt.wantJID, tt.wantLID)
+			}
+
+			s := &MessageState{Inbound: in, ChatJID: in.ChatJID, SenderJID: in.SenderJID, SenderLID: in.SenderLID, IsFromGroup: true}
+			if got := s.SenderAddress(); got != tt.wantAddress {
+				t.Errorf("address %v, want %v", got, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestRepliesQuoteSenderAddress(t *testing.T) {
+	withLIDMapping(t, aliceLID, alicePN)
+
+	for _, sender := range []waTypes.JID{aliceLID, bobPN} {
+		in := groupMessage(sender, "!check")
+		messenger := &statetest.FakeMessenger{}
+		s := &MessageState{
+			Messenger:   messenger,
+			Inbound:     in,
+			ChatJID:     in.ChatJID,
+			SenderJID:   in.SenderJID,
+			SenderLID:   in.SenderLID,
+			IsFromGroup: true,
+		}
+
+		s.Reply("hi")
+		ctxInfo := messenger.Sent[0].Message.GetExtendedTextMessage().GetContextInfo()
+		if ctxInfo.GetParticipant() != sender.String() || ctxInfo.GetStanzaID() != string(in.ID) {
+			t.Errorf("%v: reply quotes %q from %q", sender, ctxInfo.GetStanzaID(), ctxInfo.GetParticipant())
+		}
+		if messenger.Sent[0].To != mixedGroup {
+			t.Errorf("%v: reply went to %v", sender, messenger.Sent[0].To)
+		}
+	}
+}
+
+func TestDirectRepliesDoNotQuote(t *testing.T) {
+	messenger := &statetest.FakeMessenger{}
+	s := &MessageState{
+		Messenger: messenger,
+		Inbound:   &InboundMessage{ID: "ID"},
+		ChatJID:   bobPN,
+		SenderJID: bobPN,
+	}
+
+	s.Reply("hi")
+	if got := messenger.Sent[0].Message.GetConversation(); got != "hi" {
+		t.Fatalf("sent %v", messenger.Sent[0].Message)
+	}
+}
+
+func TestResolveUserJIDInMixedGroup(t *testing.T) {
+	withLIDMapping(t, aliceLID, alicePN)
+
+	in := groupMessage(bobPN, "!role @100000000000001 ADMIN", aliceLID.String())
+	s := &MessageState{Inbound: in}
+
+	tests := []struct {
+		arg  string
+		want waTypes.JID
+	}{
+		{"@" + aliceLID.User, alicePN}, // mention by LID
+		{"@" + alicePN.User, alicePN},  // typed phone number
+		{aliceLID.String(), alicePN},   // full LID JID
+		{bobPN.User, bobPN},            // bare phone number
+		{carolLID.String(), carolLID},  // unmapped LID stays as is
+	}
+
+	for _, tt := range tests {
+		got, err := s.ResolveUserJID(tt.arg)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveUserJID(%q) = %v, %v, want %v", tt.arg, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package state
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// Messenger is the part of the WhatsApp client that MessageState replies,
+// uploads and downloads through, and that handlers look contacts up with.
+// ClientMessenger adapts a *whatsmeow.Client to it, and
+// statetest.FakeMessenger stands in for it in tests.
+type Messenger interface {
+	SendMessage(ctx context.Context, to waTypes.JID, message *waProto.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error)
+	BuildEdit(chat waTypes.JID, id waTypes.MessageID, newContent *waProto.Message) *waProto.Message
+	Upload(ctx context.Context, plaintext []byte, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+	Download(msg whatsmeow.DownloadableMessage) ([]byte, error)
+	GetProfilePictureInfo(jid waTypes.JID, params *whatsmeow.GetProfilePictureParams) (*waTypes.ProfilePictureInfo, error)
+	GetContact(jid waTypes.JID) (waTypes.ContactInfo, error)
+}
+
+// ClientMessenger is a Messenger backed by a connected whatsmeow client.
+// Contacts are read from the client's store.
+type ClientMessenger struct {
+	*whatsmeow.Client
+}
+
+func (c ClientMessenger) GetContact(jid waTypes.JID) (waTypes.ContactInfo, error) {
+	return c.Store.Contacts.GetContact(jid)
+}
@@ -0,0 +1,140 @@
+// Package statetest provides a fake state.Messenger for handler tests.
+package statetest
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// FakeMessenger records what is sent and uploaded instead of talking to
+// WhatsApp. Downloads are answered from Media, keyed by the hex of the
+// media's FileSHA256.
+type FakeMessenger struct {
+	sync.Mutex
+
+	Media map[string][]byte
+
+	// Contacts and ProfilePictures answer lookups, keyed by JID. Unknown
+	// contacts are not found and unknown users have no profile picture.
+	Contacts        map[string]waTypes.ContactInfo
+	ProfilePictures map[string]*waTypes.ProfilePictureInfo
+
+	Sent      []SentMessage
+	Uploads   [][]byte
+	Downloads int
+	Lookups   []waTypes.JID
+}
+
+type SentMessage struct {
+	To      waTypes.JID
+	Message *waProto.Message
+}
+
+func (f *FakeMessenger) SendMessage(ctx context.Context, to waTypes.JID, message *waProto.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.Sent = append(f.Sent, SentMessage{To: to, Message: message})
+	return whatsmeow.SendResponse{ID: fmt.Sprintf("FAKE%d", len(f.Sent))}, nil
+}
+
+func (f *FakeMessenger) BuildEdit(chat waTypes.JID, id waTypes.MessageID, newContent *waProto.Message) *waProto.Message {
+	// BuildEdit only builds a protobuf and uses nothing from the client.
+	return (&whatsmeow.Client{}).BuildEdit(chat, id, newContent)
+}
+
+func (f *FakeMessenger) Upload(ctx context.Context, plaintext []byte, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return whatsmeow.UploadResponse{}, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.Uploads = append(f.Uploads, plaintext)
+	sum := sha256.Sum256(plaintext)
+	return whatsmeow.UploadResponse{
+		URL:           fmt.Sprintf("https://fake/%d", len(f.Uploads)),
+		DirectPath:    fmt.Sprintf("/fake/%d", len(f.Uploads)),
+		MediaKey:      []byte{byte(len(f.Uploads))},
+		FileEncSHA256: sum[:],
+		FileSHA256:    sum[:],
+		FileLength:    uint64(len(plaintext)),
+	}, nil
+}
+
+func (f *FakeMessenger) Download(msg whatsmeow.DownloadableMessage) ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.Downloads++
+	data, exists := f.Media[fmt.Sprintf("%x", msg.GetFileSHA256())]
+	if !exists {
+		return nil, fmt.Errorf("fake media %x not found", msg.GetFileSHA256())
+	}
+	return data, nil
+}
+
+func (f *FakeMessenger) GetProfilePictureInfo(jid waTypes.JID, params *whatsmeow.GetProfilePictureParams) (*waTypes.ProfilePictureInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.Lookups = append(f.Lookups, jid)
+	info, exists := f.ProfilePictures[jid.String()]
+	if !exists {
+		return nil, whatsmeow.ErrProfilePictureNotSet
+	}
+	return info, nil
+}
+
+func (f *FakeMessenger) GetContact(jid waTypes.JID) (waTypes.ContactInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.Lookups = append(f.Lookups, jid)
+	return f.Contacts[jid.String()], nil
+}
+
+// Texts returns the plain text messages and edits sent so far.
+func (f *FakeMessenger) Texts() []string {
+	f.Lock()
+	defer f.Unlock()
+
+	var texts []string
+	for _, sent := range f.Sent {
+		msg := sent.Message
+		if edited := msg.GetEditedMessage().GetMessage().GetProtocolMessage().GetEditedMessage(); edited != nil {
+			msg = edited
+		}
+		if text := msg.GetConversation(); text != "" {
+			texts = append(texts, text)
+		} else if text := msg.GetExtendedTextMessage().GetText(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// Stickers returns the sticker messages sent so far.
+func (f *FakeMessenger) Stickers() []*waProto.StickerMessage {
+	f.Lock()
+	defer f.Unlock()
+
+	var stickers []*waProto.StickerMessage
+	for _, sent := range f.Sent {
+		if sticker := sent.Message.GetStickerMessage(); sticker != nil {
+			stickers = append(stickers, sticker)
+		}
+	}
+	return stickers
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"testing"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+func testMessageState(chat, sender waTypes.JID) *MessageState {
+	return &MessageState{ChatJID: chat, SenderJID: sender, IsFromGroup: chat.Server == waTypes.GroupServer}
+}
+
+func TestProcessStatePerParticipant(t *testing.T) {
+	group := waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	alice := testMessageState(group, waTypes.NewJID("6281200000001", waTypes.DefaultUserServer))
+	bob := testMessageState(group, waTypes.NewJID("6281200000002", waTypes.DefaultUserServer))
+	t.Cleanup(func() {
+		alice.ClearUserState()
+		bob.ClearUserState()
+	})
+
+	aliceCanceled, bobCanceled := false, false
+	alice.AddUserToState("processing", func() { aliceCanceled = true })
+
+	if bob.CheckUserState() != "" {
+		t.Fatal("bob is blocked by alice's job")
+	}
+
+	bob.AddUserToState("processing", func() { bobCanceled = true })
+	if alice.CheckUserState() != "processing" || bob.CheckUserState() != "processing" {
+		t.Fatal("both jobs should be running")
+	}
+
+	if err := bob.CancelCurrentProcess(); err != nil {
+		t.Fatal(err)
+	}
+	if !bobCanceled || aliceCanceled {
+		t.Fatalf("bob's cancel canceled bob=%v alice=%v", bobCanceled, aliceCanceled)
+	}
+	if alice.CheckUserState() != "processing" || bob.CheckUserState() != "" {
+		t.Fatal("only bob's job should be gone")
+	}
+
+	if err := bob.CancelCurrentProcess(); err == nil {
+		t.Fatal("bob has nothing left to cancel")
+	}
+
+	if err := alice.CancelCurrentProcess(); err != nil || !aliceCanceled {
+		t.Fatalf("alice's cancel: %v, canceled=%v", err, aliceCanceled)
+	}
+}
+
+func TestProcessStateAcrossChats(t *testing.T) {
+	withLIDMapping(t, aliceLID, alicePN)
+
+	otherGroup := waTypes.NewJID("120363000000000002", waTypes.GroupServer)
+	inGroup := testMessageState(mixedGroup, alicePN)
+	inOtherGroup := testMessageState(otherGroup, aliceLID)
+	inDM := testMessageState(alicePN, alicePN)
+	t.Cleanup(inGroup.ClearUserState)
+
+	inGroup.AddUserToState("processing", func() {})
+
+	if inOtherGroup.CheckUserState() != "processing" {
+		t.Error("alice can start a second job from another group")
+	}
+	if inDM.CheckUserState() != "processing" {
+		t.Error("alice can start a second job from her DM")
+	}
+
+	if err := inDM.CancelCurrentProcess(); err != nil {
+		t.Fatalf("alice cannot cancel her group job from her DM: %v", err)
+	}
+	if inGroup.CheckUserState() != "" {
+		t.Fatal("the group job is still running")
+	}
+}
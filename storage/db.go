@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DB is the bot's own handle on the session database. The bot_* tables live
+// next to whatsmeow's tables in the same SQLite file.
+var DB *sql.DB
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS bot_roles (
+		jid        TEXT PRIMARY KEY,
+		role       TEXT NOT NULL,
+		granted_by TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_lid_map (
+		lid TEXT PRIMARY KEY,
+		pn  TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_group_settings (
+		group_jid         TEXT PRIMARY KEY,
+		allowed           INTEGER NOT NULL DEFAULT 0,
+		enabled           INTEGER NOT NULL DEFAULT 1,
+		disabled_commands TEXT NOT NULL DEFAULT '',
+		language          TEXT NOT NULL DEFAULT '',
+		prefix            TEXT NOT NULL DEFAULT '!',
+		sticker_quota     INTEGER NOT NULL DEFAULT 0,
+		welcome_text      TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_sticker_usage (
+		group_jid TEXT NOT NULL,
+		user_jid  TEXT NOT NULL,
+		day       TEXT NOT NULL,
+		count     INTEGER NOT NULL,
+		PRIMARY KEY (group_jid, user_jid, day)
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_user_settings (
+		jid      TEXT PRIMARY KEY,
+		language TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_sticker_prefs (
+		jid       TEXT PRIMARY KEY,
+		pack_name TEXT NOT NULL DEFAULT '',
+		author    TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_sticker_packs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner_jid   TEXT NOT NULL,
+		name        TEXT NOT NULL COLLATE NOCASE,
+		created_at  INTEGER NOT NULL,
+		selected_at INTEGER NOT NULL,
+		UNIQUE (owner_jid, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_sticker_pack_items (
+		pack_id  INTEGER NOT NULL REFERENCES bot_sticker_packs(id) ON DELETE CASCADE,
+		hash     TEXT NOT NULL,
+		path     TEXT NOT NULL,
+		added_at INTEGER NOT NULL,
+		PRIMARY KEY (pack_id, hash)
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_sticker_cache (
+		key        TEXT PRIMARY KEY,
+		path       TEXT NOT NULL,
+		size       INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		used_at    INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_upload_cache (
+		hash            TEXT PRIMARY KEY,
+		url             TEXT NOT NULL,
+		direct_path     TEXT NOT NULL,
+		media_key       BLOB NOT NULL,
+		file_enc_sha256 BLOB NOT NULL,
+		file_sha256     BLOB NOT NULL,
+		file_length     INTEGER NOT NULL,
+		created_at      INTEGER NOT NULL
+	)`,
+}
+
+// busyTimeout is how long, in milliseconds, a connection waits for a write
+// lock before failing with "database is locked". The database file is shared
+// with the whatsmeow session store and the cleanup cron job.
+const busyTimeout = 5000
+
+// Open opens dataSource and creates the bot's tables. A busy timeout is
+// added unless dataSource sets one.
+func Open(dataSource string) error {
+	if !strings.Contains(dataSource, "_busy_timeout") {
+		separator := "?"
+		if strings.Contains(dataSource, "?") {
+			separator = "&"
+		}
+		dataSource += fmt.Sprintf("%s_busy_timeout=%d", separator, busyTimeout)
+	}
+
+	db, err := sql.Open("sqlite3", dataSource)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	for _, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			db.Close()
+			return fmt.Errorf("failed to migrate database: %v", err)
+		}
+	}
+
+	DB = db
+	return nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenSetsBusyTimeout(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		dsn  string
+		want int
+	}{
+		{"file:" + filepath.Join(dir, "a.db"), busyTimeout},
+		{"file:" + filepath.Join(dir, "b.db") + "?_foreign_keys=on", busyTimeout},
+		{"file:" + filepath.Join(dir, "c.db") + "?_busy_timeout=100", 100},
+	}
+
+	for _, tt := range tests {
+		if err := Open(tt.dsn); err != nil {
+			t.Fatal(err)
+		}
+		var timeout int
+		err := DB.QueryRow("PRAGMA busy_timeout").Scan(&timeout)
+		DB.Close()
+		if err != nil || timeout != tt.want {
+			t.Errorf("%s: busy_timeout %d, %v, want %d", tt.dsn, timeout, err, tt.want)
+		}
+	}
+}
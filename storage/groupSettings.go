@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+type GroupSettings struct {
+	GroupJID         string
+	Allowed          bool
+	Enabled          bool
+	DisabledCommands []string
+	Language         string
+	Prefix           string
+	StickerQuota     int
+	WelcomeText      string
+}
+
+var (
+	groupSettingsMu    sync.RWMutex
+	groupSettingsCache = make(map[string]GroupSettings)
+)
+
+func DefaultGroupSettings(groupJID string) GroupSettings {
+	return GroupSettings{
+		GroupJID: groupJID,
+		Enabled:  true,
+		Prefix:   "!",
+	}
+}
+
+// GetGroupSettings returns the stored settings of a group, or the defaults
+// when the group was never configured. Results are cached in memory since
+// they are read for every group message.
+func GetGroupSettings(groupJID string) (GroupSettings, error) {
+	groupSettingsMu.RLock()
+	settings, cached := groupSettingsCache[groupJID]
+	groupSettingsMu.RUnlock()
+	if cached {
+		return settings, nil
+	}
+
+	settings = DefaultGroupSettings(groupJID)
+	if DB == nil {
+		return settings, nil
+	}
+
+	var disabled string
+	err := DB.QueryRow(`
+		SELECT allowed, enabled, disabled_commands, language, prefix, sticker_quota, welcome_text
+		FROM bot_group_settings WHERE group_jid = ?`, groupJID,
+	).Scan(&settings.Allowed, &settings.Enabled, &disabled, &settings.Language,
+		&settings.Prefix, &settings.StickerQuota, &settings.WelcomeText)
+	if err != nil && err != sql.ErrNoRows {
+		return settings, err
+	}
+	if disabled != "" {
+		settings.DisabledCommands = strings.Split(disabled, ",")
+	}
+
+	groupSettingsMu.Lock()
+	groupSettingsCache[groupJID] = settings
+	groupSettingsMu.Unlock()
+
+	return settings, nil
+}
+
+func SaveGroupSettings(settings GroupSettings) error {
+	_, err := DB.Exec(`
+		INSERT INTO bot_group_settings
+			(group_jid, allowed, enabled, disabled_commands, language, prefix, sticker_quota, welcome_text)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(group_jid) DO UPDATE SET
+			allowed = excluded.allowed,
+			enabled = excluded.enabled,
+			disabled_commands = excluded.disabled_commands,
+			language = excluded.language,
+			prefix = excluded.prefix,
+			sticker_quota = excluded.sticker_quota,
+			welcome_text = excluded.welcome_text`,
+		settings.GroupJID, settings.Allowed, settings.Enabled, strings.Join(settings.DisabledCommands, ","),
+		settings.Language, settings.Prefix, settings.StickerQuota, settings.WelcomeText,
+	)
+	if err != nil {
+		return err
+	}
+
+	groupSettingsMu.Lock()
+	groupSettingsCache[settings.GroupJID] = settings
+	groupSettingsMu.Unlock()
+
+	return nil
+}
+
+// IncrementStickerUsage counts one sticker for user in group on day and
+// returns the new total for that day.
+func IncrementStickerUsage(groupJID, userJID, day string) (int, error) {
+	_, err := DB.Exec(`
+		INSERT INTO bot_sticker_usage (group_jid, user_jid, day, count) VALUES (?, ?, ?, 1)
+		ON CONFLICT(group_jid, user_jid, day) DO UPDATE SET count = count + 1`,
+		groupJID, userJID, day,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return GetStickerUsage(groupJID, userJID, day)
+}
+
+func GetStickerUsage(groupJID, userJID, day string) (int, error) {
+	var count int
+	err := DB.QueryRow(
+		"SELECT count FROM bot_sticker_usage WHERE group_jid = ? AND user_jid = ? AND day = ?",
+		groupJID, userJID, day,
+	).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return count, err
+}
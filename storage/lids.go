@@ -0,0 +1,31 @@
+package storage
+
+// PutLIDMapping remembers which phone number JID a linked identity (@lid)
+// belongs to.
+func PutLIDMapping(lid, pn string) error {
+	_, err := DB.Exec(`
+		INSERT INTO bot_lid_map (lid, pn) VALUES (?, ?)
+		ON CONFLICT(lid) DO UPDATE SET pn = excluded.pn`,
+		lid, pn,
+	)
+	return err
+}
+
+func GetLIDMappings() (map[string]string, error) {
+	rows, err := DB.Query("SELECT lid, pn FROM bot_lid_map")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := make(map[string]string)
+	for rows.Next() {
+		var lid, pn string
+		if err := rows.Scan(&lid, &pn); err != nil {
+			return nil, err
+		}
+		mappings[lid] = pn
+	}
+
+	return mappings, rows.Err()
+}
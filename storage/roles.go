@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+type RoleGrant struct {
+	JID       string
+	Role      string
+	GrantedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// GrantRole stores a role for jid, replacing any previous grant. A zero
+// expiresAt makes the grant permanent.
+func GrantRole(jid, role, grantedBy string, expiresAt time.Time) error {
+	var expires sql.NullInt64
+	if !expiresAt.IsZero() {
+		expires = sql.NullInt64{Int64: expiresAt.Unix(), Valid: true}
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO bot_roles (jid, role, granted_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			role = excluded.role,
+			granted_by = excluded.granted_by,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at`,
+		jid, role, grantedBy, time.Now().Unix(), expires,
+	)
+	return err
+}
+
+func RevokeRole(jid string) (bool, error) {
+	result, err := DB.Exec("DELETE FROM bot_roles WHERE jid = ?", jid)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetRole returns the active (non-expired) role granted to jid.
+func GetRole(jid string) (string, bool, error) {
+	if DB == nil {
+		return "", false, nil
+	}
+
+	var role string
+	err := DB.QueryRow(
+		"SELECT role FROM bot_roles WHERE jid = ? AND (expires_at IS NULL OR expires_at > ?)",
+		jid, time.Now().Unix(),
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return role, true, nil
+}
+
+// ListRoles removes expired grants and returns the remaining ones.
+func ListRoles() ([]RoleGrant, error) {
+	if _, err := DB.Exec("DELETE FROM bot_roles WHERE expires_at IS NOT NULL AND expires_at <= ?", time.Now().Unix()); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query("SELECT jid, role, granted_by, created_at, expires_at FROM bot_roles ORDER BY role, jid")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []RoleGrant
+	for rows.Next() {
+		var grant RoleGrant
+		var createdAt int64
+		var expiresAt sql.NullInt64
+
+		if err := rows.Scan(&grant.JID, &grant.Role, &grant.GrantedBy, &createdAt, &expiresAt); err != nil {
+			return nil, err
+		}
+
+		grant.CreatedAt = time.Unix(createdAt, 0)
+		if expiresAt.Valid {
+			grant.ExpiresAt = time.Unix(expiresAt.Int64, 0)
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// StickerCacheDir holds converted stickers, named by their cache key. Entries
+// are evicted by age and by total size whenever a new one is added.
+const StickerCacheDir = "cache/stickers"
+
+// UploadReuseTTL is how long an uploaded sticker is re-sent by its direct
+// path before it is uploaded again. WhatsApp accepts a message pointing at an
+// expired path and the recipient simply fails to download it, so the TTL is
+// kept short and an earlier expiry in the path itself is honoured too.
+const UploadReuseTTL = 24 * time.Hour
+
+// uploadExpiryMargin keeps a re-sent path valid long enough for recipients
+// to download it.
+const uploadExpiryMargin = time.Hour
+
+var ErrorCacheMiss = errors.New("not in cache")
+
+func stickerCacheLimits() (maxBytes int64, maxAge time.Duration) {
+	mb, err := strconv.Atoi(os.Getenv("STICKER_CACHE_MB"))
+	if err != nil || mb <= 0 {
+		mb = 500
+	}
+	days, err := strconv.Atoi(os.Getenv("STICKER_CACHE_DAYS"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	return int64(mb) << 20, time.Duration(days) * 24 * time.Hour
+}
+
+// GetCachedSticker returns the path of the cached conversion stored under
+// key, and marks it as recently used.
+func GetCachedSticker(key string) (string, error) {
+	_, maxAge := stickerCacheLimits()
+
+	var path string
+	err := DB.QueryRow("SELECT path FROM bot_sticker_cache WHERE key = ? AND created_at > ?",
+		key, time.Now().Add(-maxAge).Unix(),
+	).Scan(&path)
+	if err == sql.ErrNoRows {
+		return "", ErrorCacheMiss
+	} else if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		DB.Exec("DELETE FROM bot_sticker_cache WHERE key = ?", key)
+		return "", ErrorCacheMiss
+	}
+
+	_, err = DB.Exec("UPDATE bot_sticker_cache SET used_at = ? WHERE key = ?", time.Now().UnixNano(), key)
+	return path, err
+}
+
+// PutCachedSticker copies a converted WebP into the cache under key, then
+// evicts expired entries and the least recently used ones over the size
+// limit.
+func PutCachedSticker(key, webpPath string) error {
+	data, err := os.ReadFile(webpPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(StickerCacheDir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(StickerCacheDir, key+".webp")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = DB.Exec(`
+		INSERT INTO bot_sticker_cache (key, path, size, created_at, used_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			path = excluded.path, size = excluded.size,
+			created_at = excluded.created_at, used_at = excluded.used_at`,
+		key, path, len(data), now.Unix(), now.UnixNano(),
+	)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return evictStickerCache()
+}
+
+func evictStickerCache() error {
+	maxBytes, maxAge := stickerCacheLimits()
+
+	rows, err := DB.Query("SELECT key, path, size, created_at FROM bot_sticker_cache ORDER BY used_at DESC")
+	if err != nil {
+		return err
+	}
+
+	var evicted []string
+	var total int64
+	cutoff := time.Now().Add(-maxAge).Unix()
+	for rows.Next() {
+		var key, path string
+		var size, createdAt int64
+		if err := rows.Scan(&key, &path, &size, &createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+
+		total += size
+		if createdAt <= cutoff || total > maxBytes {
+			os.Remove(path)
+			evicted = append(evicted, key)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range evicted {
+		if _, err := DB.Exec("DELETE FROM bot_sticker_cache WHERE key = ?", key); err != nil {
+			return err
+		}
+	}
+	if len(evicted) > 0 {
+		fmt.Printf("Sticker cache: evicted %d entries\n", len(evicted))
+	}
+	return nil
+}
+
+// GetCachedUpload returns the upload of the file with the given hash, if it
+// was uploaded within UploadReuseTTL and its direct path has not expired.
+func GetCachedUpload(hash string) (*whatsmeow.UploadResponse, error) {
+	var uploaded whatsmeow.UploadResponse
+	err := DB.QueryRow(`
+		SELECT url, direct_path, media_key, file_enc_sha256, file_sha256, file_length
+		FROM bot_upload_cache WHERE hash = ? AND created_at > ?`,
+		hash, time.Now().Add(-UploadReuseTTL).Unix(),
+	).Scan(&uploaded.URL, &uploaded.DirectPath, &uploaded.MediaKey,
+		&uploaded.FileEncSHA256, &uploaded.FileSHA256, &uploaded.FileLength)
+	if err == sql.ErrNoRows {
+		return nil, ErrorCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	if expiry, ok := directPathExpiry(uploaded.DirectPath); ok && time.Now().Add(uploadExpiryMargin).After(expiry) {
+		DB.Exec("DELETE FROM bot_upload_cache WHERE hash = ?", hash)
+		return nil, ErrorCacheMiss
+	}
+	return &uploaded, nil
+}
+
+// directPathExpiry reads the expiry WhatsApp puts in media paths as the oe
+// query parameter, a hex Unix timestamp.
+func directPathExpiry(directPath string) (time.Time, bool) {
+	parsed, err := url.Parse(directPath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	oe, err := strconv.ParseInt(parsed.Query().Get("oe"), 16, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(oe, 0), true
+}
+
+// SaveCachedUpload remembers an upload so the same file can be re-sent
+// without uploading it again. Expired uploads are dropped.
+func SaveCachedUpload(hash string, uploaded *whatsmeow.UploadResponse) error {
+	now := time.Now()
+	_, err := DB.Exec(`
+		INSERT INTO bot_upload_cache (hash, url, direct_path, media_key, file_enc_sha256, file_sha256, file_length, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(hash) DO UPDATE SET
+			url = excluded.url, direct_path = excluded.direct_path, media_key = excluded.media_key,
+			file_enc_sha256 = excluded.file_enc_sha256, file_sha256 = excluded.file_sha256,
+			file_length = excluded.file_length, created_at = excluded.created_at`,
+		hash, uploaded.URL, uploaded.DirectPath, uploaded.MediaKey,
+		uploaded.FileEncSHA256, uploaded.FileSHA256, uploaded.FileLength, now.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec("DELETE FROM bot_upload_cache WHERE created_at <= ?", now.Add(-UploadReuseTTL).Unix())
+	return err
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := Open("file:" + filepath.Join(t.TempDir(), "bot.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.Close() })
+}
+
+func directPathExpiringAt(expiry time.Time) string {
+	return fmt.Sprintf("/v/t62.15575-24/1234_5678_n.enc?ccb=11-4&oh=01_Q5&oe=%X&_nc_sid=5e03e0", expiry.Unix())
+}
+
+func TestDirectPathExpiry(t *testing.T) {
+	expiry := time.Unix(0x6612ABCD, 0)
+
+	tests := []struct {
+		path string
+		want time.Time
+		ok   bool
+	}{
+		{directPathExpiringAt(expiry), expiry, true},
+		{"/fake/1", time.Time{}, false},
+		{"/v/t62/file.enc?oe=nothex", time.Time{}, false},
+		{"%zz", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := directPathExpiry(tt.path)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("directPathExpiry(%q) = %v %v, want %v %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGetCachedUploadExpiry(t *testing.T) {
+	openTestDB(t)
+
+	tests := []struct {
+		name       string
+		directPath string
+		wantHit    bool
+	}{
+		{"no expiry in path", "/fake/1", true},
+		{"valid path", directPathExpiringAt(time.Now().Add(48 * time.Hour)), true},
+		{"expired path", directPathExpiringAt(time.Now().Add(-time.Minute)), false},
+		{"expires within the margin", directPathExpiringAt(time.Now().Add(uploadExpiryMargin / 2)), false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := fmt.Sprint(i)
+			uploaded := &whatsmeow.UploadResponse{
+				URL:           "https://mmg.whatsapp.net" + tt.directPath,
+				DirectPath:    tt.directPath,
+				MediaKey:      []byte{1},
+				FileEncSHA256: []byte{2},
+				FileSHA256:    []byte{3},
+				FileLength:    4,
+			}
+			if err := SaveCachedUpload(hash, uploaded); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := GetCachedUpload(hash)
+			if tt.wantHit {
+				if err != nil || got.DirectPath != tt.directPath {
+					t.Fatalf("got %v, %v", got, err)
+				}
+			} else if !errors.Is(err, ErrorCacheMiss) {
+				t.Fatalf("got %v, want a miss", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// PackDir holds the WebP files of sticker packs, one directory per pack. The
+// database only stores their paths.
+const PackDir = "stickerpacks"
+
+var (
+	ErrorPackNotFound   = errors.New("sticker pack not found")
+	ErrorStickerInPack  = errors.New("sticker already in pack")
+	ErrorPackNameExists = errors.New("sticker pack name already exists")
+)
+
+// StickerPack is a user's named collection of stickers. The current pack is
+// the one most recently created or selected.
+type StickerPack struct {
+	ID       int64
+	OwnerJID string
+	Name     string
+	Count    int
+}
+
+const packColumns = `p.id, p.owner_jid, p.name,
+	(SELECT COUNT(*) FROM bot_sticker_pack_items i WHERE i.pack_id = p.id)`
+
+func scanPack(row interface{ Scan(...any) error }) (StickerPack, error) {
+	var pack StickerPack
+	err := row.Scan(&pack.ID, &pack.OwnerJID, &pack.Name, &pack.Count)
+	if err == sql.ErrNoRows {
+		return pack, ErrorPackNotFound
+	}
+	return pack, err
+}
+
+// CreateStickerPack creates a pack and makes it the owner's current pack.
+func CreateStickerPack(ownerJID, name string) (StickerPack, error) {
+	now := time.Now().UnixNano()
+	result, err := DB.Exec(`
+		INSERT INTO bot_sticker_packs (owner_jid, name, created_at, selected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(owner_jid, name) DO NOTHING`,
+		ownerJID, name, now, now,
+	)
+	if err != nil {
+		return StickerPack{}, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return StickerPack{}, ErrorPackNameExists
+	}
+
+	id, err := result.LastInsertId()
+	return StickerPack{ID: id, OwnerJID: ownerJID, Name: name}, err
+}
+
+// SelectStickerPack makes an existing pack the owner's current pack.
+func SelectStickerPack(ownerJID, name string) (StickerPack, error) {
+	pack, err := GetStickerPack(ownerJID, name)
+	if err != nil {
+		return pack, err
+	}
+
+	_, err = DB.Exec("UPDATE bot_sticker_packs SET selected_at = ? WHERE id = ?", time.Now().UnixNano(), pack.ID)
+	return pack, err
+}
+
+func GetStickerPack(ownerJID, name string) (StickerPack, error) {
+	return scanPack(DB.QueryRow(`SELECT `+packColumns+`
+		FROM bot_sticker_packs p WHERE p.owner_jid = ? AND p.name = ?`,
+		ownerJID, name,
+	))
+}
+
+func GetCurrentStickerPack(ownerJID string) (StickerPack, error) {
+	return scanPack(DB.QueryRow(`SELECT `+packColumns+`
+		FROM bot_sticker_packs p WHERE p.owner_jid = ?
+		ORDER BY p.selected_at DESC LIMIT 1`,
+		ownerJID,
+	))
+}
+
+// ListStickerPacks returns the owner's packs, the current one first.
+func ListStickerPacks(ownerJID string) ([]StickerPack, error) {
+	rows, err := DB.Query(`SELECT `+packColumns+`
+		FROM bot_sticker_packs p WHERE p.owner_jid = ?
+		ORDER BY p.selected_at DESC`,
+		ownerJID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packs []StickerPack
+	for rows.Next() {
+		pack, err := scanPack(rows)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	return packs, rows.Err()
+}
+
+// AddPackSticker saves a WebP sticker to the pack. The same sticker can only
+// be added once.
+func AddPackSticker(packID int64, data []byte) error {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	dir := filepath.Join(PackDir, strconv.FormatInt(packID, 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, hash[:16]+".webp")
+
+	result, err := DB.Exec(`
+		INSERT INTO bot_sticker_pack_items (pack_id, hash, path, added_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(pack_id, hash) DO NOTHING`,
+		packID, hash, path, time.Now().Unix(),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrorStickerInPack
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		DB.Exec("DELETE FROM bot_sticker_pack_items WHERE pack_id = ? AND hash = ?", packID, hash)
+		return err
+	}
+	return nil
+}
+
+// GetPackStickers reads the pack's stickers in the order they were added.
+func GetPackStickers(packID int64) ([][]byte, error) {
+	rows, err := DB.Query("SELECT path FROM bot_sticker_pack_items WHERE pack_id = ? ORDER BY added_at, rowid", packID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var stickers [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		stickers = append(stickers, data)
+	}
+	return stickers, nil
+}
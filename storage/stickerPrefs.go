@@ -0,0 +1,37 @@
+package storage
+
+import "database/sql"
+
+// StickerPrefs are a user's default sticker pack name and author. Empty
+// fields mean the bot-wide default is used.
+type StickerPrefs struct {
+	JID      string
+	PackName string
+	Author   string
+}
+
+func GetStickerPrefs(jid string) (StickerPrefs, error) {
+	prefs := StickerPrefs{JID: jid}
+	if DB == nil {
+		return prefs, nil
+	}
+
+	err := DB.QueryRow(
+		"SELECT pack_name, author FROM bot_sticker_prefs WHERE jid = ?", jid,
+	).Scan(&prefs.PackName, &prefs.Author)
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	}
+	return prefs, err
+}
+
+func SaveStickerPrefs(prefs StickerPrefs) error {
+	_, err := DB.Exec(`
+		INSERT INTO bot_sticker_prefs (jid, pack_name, author) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			pack_name = excluded.pack_name,
+			author = excluded.author`,
+		prefs.JID, prefs.PackName, prefs.Author,
+	)
+	return err
+}
@@ -0,0 +1,27 @@
+package storage
+
+import "database/sql"
+
+// GetUserLanguage returns the language a user picked with !lang, or an empty
+// string when they never set one.
+func GetUserLanguage(jid string) (string, error) {
+	if DB == nil {
+		return "", nil
+	}
+
+	var language string
+	err := DB.QueryRow("SELECT language FROM bot_user_settings WHERE jid = ?", jid).Scan(&language)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return language, err
+}
+
+func SetUserLanguage(jid, language string) error {
+	_, err := DB.Exec(`
+		INSERT INTO bot_user_settings (jid, language) VALUES (?, ?)
+		ON CONFLICT(jid) DO UPDATE SET language = excluded.language`,
+		jid, language,
+	)
+	return err
+}
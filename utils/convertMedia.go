@@ -5,6 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -21,13 +26,49 @@ type StickerOptions struct {
 	Direction  string
 	FPS        int
 	IsAnimated bool
+
+	// Size and MaxDuration are tuned by FitWebp rather than by the user. Size
+	// below 512 downscales the frames before scaling them back to 512x512.
+	Size        int
+	MaxDuration int
+
+	// Overlay is an optional 512x512 PNG drawn over every frame, used for
+	// meme captions.
+	Overlay string
+
+	// Effects applied after cropping. Speed, Reverse and Boomerang only
+	// apply to animated media.
+	Shape     string       // "circle", "rounded" or "square"
+	Border    *color.NRGBA // drawn along the edge of Shape
+	Flip      bool         // upside down
+	Mirror    bool         // left to right
+	Rotate    int          // 90, 180 or 270 degrees clockwise
+	Grayscale bool
+	Speed     float64 // 0.5 to 4, 0 means normal speed
+	Reverse   bool
+	Boomerang bool
+
+	// RemoveBackground keys out BackgroundColor, or the color detected in
+	// the corners when it is nil. BackgroundSimilarity is the colorkey
+	// tolerance, 0.15 when zero.
+	RemoveBackground     bool
+	BackgroundColor      *color.NRGBA
+	BackgroundSimilarity float64
+
+	// Metadata holds the inline pack=, author= and emoji= values. Empty
+	// fields fall back to the sender's saved defaults.
+	Metadata StickerMetadata
 }
 
 var ErrorNotUnder1MB = errors.New("failed to convert to webp under 1MB")
 
+// ConvertToWebp converts a media file to a 512x512 WebP sticker with the
+// current Converter. Unset options are filled with their defaults.
 func ConvertToWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (string, error) {
-	webpPath := filepath.Join("media", fmt.Sprintf("output_%d.webp", time.Now().UnixMilli()))
+	return Converter.ConvertToWebp(ctx, mediaPath, opt)
+}
 
+func applyWebpDefaults(opt *StickerOptions) {
 	if opt.FPS == 0 {
 		opt.FPS = 15
 	}
@@ -36,97 +77,275 @@ func ConvertToWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (
 		opt.Quality = 100
 	}
 
-	parseDirection := func() (string, int) {
-		parts := strings.Split(opt.Direction, "-")
-		side := parts[0]
-		level := 0
+	if opt.Size == 0 {
+		opt.Size = 512
+	}
+
+	if opt.MaxDuration == 0 {
+		opt.MaxDuration = 30
+	}
+}
+
+// webpArgs fills in the defaults of opt, detects the background color when
+// bg=remove was given without one, and returns the ffmpeg arguments.
+func webpArgs(ctx context.Context, mediaPath, webpPath string, opt *StickerOptions) ([]string, error) {
+	applyWebpDefaults(opt)
 
-		if len(parts) == 2 {
-			if n, err := strconv.Atoi(parts[1]); err == nil {
-				level = n
+	var background *color.NRGBA
+	if opt.RemoveBackground {
+		background = opt.BackgroundColor
+		if background == nil {
+			detected, found, err := DetectBackgroundColor(ctx, mediaPath, opt.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("detect background: %w", err)
+			}
+			if found {
+				background = &detected
 			}
 		}
+	}
+
+	return BuildFFmpegArgs(mediaPath, webpPath, opt, background), nil
+}
 
-		return side, level
+func (FFmpegConverter) ConvertToWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (string, error) {
+	// Chats are handled in parallel, so the output needs a name no other
+	// conversion can pick.
+	webpFile, err := os.CreateTemp("", "sticker-*.webp")
+	if err != nil {
+		return "", err
 	}
+	webpFile.Close()
+	webpPath := webpFile.Name()
 
-	getCropFilter := func() string {
-		base := "crop=min(iw\\,ih):min(iw\\,ih)"
-		side, percent := parseDirection()
+	args, err := webpArgs(ctx, mediaPath, webpPath, opt)
+	if err != nil {
+		return webpPath, err
+	}
 
-		ratio := float64(percent) / 100
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
-		switch side {
-		case "up":
-			return fmt.Sprintf("%s:0:round((ih-min(iw\\,ih))*(1-%f))", base, ratio)
-		case "down":
-			return fmt.Sprintf("%s:0:round((ih-min(iw\\,ih))*%f)", base, ratio)
-		case "left":
-			return fmt.Sprintf("%s:round((iw-min(iw\\,ih))*%f):0", base, ratio)
-		case "right":
-			return fmt.Sprintf("%s:round((iw-min(iw\\,ih))*(1-%f)):0", base, ratio)
-		default:
-			return base
+	err = cmd.Run()
+	if err != nil {
+		// A killed or failed ffmpeg only means the job was canceled when the
+		// context says so; otherwise it is a real failure, such as running
+		// out of memory.
+		if ctx.Err() != nil {
+			return webpPath, ctx.Err()
 		}
+
+		fmt.Println("FFmpeg failed:", stderr.String())
+		return webpPath, err
+	}
+
+	return webpPath, checkWebpSize(webpPath)
+}
+
+func checkWebpSize(webpPath string) error {
+	info, err := os.Stat(webpPath)
+	if err == nil && info.Size() <= 1024*1024 {
+		return nil
+	}
+	return ErrorNotUnder1MB
+}
+
+// BuildFFmpegArgs returns the ffmpeg arguments that convert mediaPath to a
+// WebP sticker at outputPath. opt must already have its defaults applied, and
+// background is the color to key out, if any. It runs nothing, so the filter
+// chain can be checked without ffmpeg installed.
+func BuildFFmpegArgs(mediaPath, outputPath string, opt *StickerOptions, background *color.NRGBA) []string {
+	resize := ""
+	if opt.Size < 512 {
+		resize = fmt.Sprintf(",scale=%d:%d,scale=512:512", opt.Size, opt.Size)
 	}
 
 	var args []string
+	var inputTime, outputTime []string
+	if opt.IsAnimated {
+		inputTime, outputTime = timeArgs(opt)
+	}
+	args = append(args, inputTime...)
 	args = append(args, "-i", mediaPath)
+	if opt.Overlay != "" {
+		args = append(args, "-i", opt.Overlay)
+	}
+	args = append(args, outputTime...)
 
+	var filters []string
 	if opt.IsAnimated {
-		if opt.StartTime != "" {
-			args = append(args, "-ss", opt.StartTime)
-		}
-		if opt.EndTime != "" {
-			args = append(args, "-to", opt.EndTime)
-		} else {
-			args = append(args, "-t", "30")
-		}
-		if opt.NoCrop {
-			args = append(args, "-vf", fmt.Sprintf("fps=%d,%s", opt.FPS,
-				"scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000@0"))
-		} else {
-			args = append(args, "-vf", fmt.Sprintf("fps=%d,%s,scale=512:512", opt.FPS, getCropFilter()))
-		}
+		filters = append(filters, timeFilters(opt)...)
+		filters = append(filters, fmt.Sprintf("fps=%d", opt.FPS))
+	}
+	if background != nil {
+		filters = append(filters, backgroundFilter(*background, opt.BackgroundSimilarity))
+	}
+	if opt.NoCrop {
+		filters = append(filters, "scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000@0"+resize)
 	} else {
-		if opt.NoCrop {
-			args = append(args, "-vf", "scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000@0")
-		} else {
-			args = append(args, "-vf", getCropFilter()+",scale=512:512")
+		filters = append(filters, cropFilter(opt.Direction)+",scale=512:512"+resize)
+	}
+	filters = append(filters, effectFilters(opt)...)
+	if opt.IsAnimated {
+		if opt.Boomerang {
+			filters = append(filters, boomerangFilter)
+		} else if opt.Reverse {
+			filters = append(filters, "reverse")
 		}
 	}
+	filter := strings.Join(filters, ",")
+
+	if opt.Overlay != "" {
+		args = append(args, "-filter_complex", "[0:v]"+filter+",format=rgba[base];[base][1:v]overlay=0:0")
+	} else if opt.IsAnimated && opt.Boomerang {
+		args = append(args, "-filter_complex", "[0:v]"+filter)
+	} else {
+		args = append(args, "-vf", filter)
+	}
 
-	args = append(args,
+	return append(args,
 		"-quality", fmt.Sprintf("%d", opt.Quality),
 		"-pix_fmt", "rgba",
-		"-y", webpPath,
+		"-y", outputPath,
 	)
+}
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+// cropFilter crops the largest centered square, or shifts it towards a side
+// for directions such as "up" or "left-20".
+func cropFilter(direction string) string {
+	parts := strings.Split(direction, "-")
+	side := parts[0]
+	percent := 0
+	if len(parts) == 2 {
+		if n, err := strconv.Atoi(parts[1]); err == nil {
+			percent = n
+		}
+	}
+
+	base := "crop=min(iw\\,ih):min(iw\\,ih)"
+	ratio := float64(percent) / 100
+
+	switch side {
+	case "up":
+		return fmt.Sprintf("%s:0:round((ih-min(iw\\,ih))*(1-%f))", base, ratio)
+	case "down":
+		return fmt.Sprintf("%s:0:round((ih-min(iw\\,ih))*%f)", base, ratio)
+	case "left":
+		return fmt.Sprintf("%s:round((iw-min(iw\\,ih))*%f):0", base, ratio)
+	case "right":
+		return fmt.Sprintf("%s:round((iw-min(iw\\,ih))*(1-%f)):0", base, ratio)
+	default:
+		return base
+	}
+}
+
+var ErrorNotAnimated = errors.New("sticker is not animated")
+
+// ConvertWebpToPng saves the first frame of a WebP sticker as a PNG.
+func ConvertWebpToPng(webpPath string) (string, error) {
+	pngPath := filepath.Join("media", fmt.Sprintf("output_%d.png", time.Now().UnixNano()))
 
-	err := cmd.Run()
+	data, err := os.ReadFile(webpPath)
 	if err != nil {
-		if strings.Contains(err.Error(), "signal: killed") {
-			return webpPath, context.Canceled
+		return pngPath, err
+	}
+
+	frames, _, err := DecodeWebpFrames(data)
+	if err != nil {
+		return pngPath, err
+	}
+
+	return pngPath, writePng(pngPath, frames[0])
+}
+
+// ConvertWebpToMp4 renders an animated WebP sticker to an H.264 MP4 over a
+// white background with the current Converter. It returns the video length in
+// seconds.
+func ConvertWebpToMp4(ctx context.Context, webpPath string) (string, int, error) {
+	return Converter.WebpToMp4(ctx, webpPath)
+}
+
+// WebpToMp4 decodes the frames in Go since ffmpeg cannot decode animated
+// WebP, then feeds them to ffmpeg through the concat demuxer so each frame
+// keeps its own duration.
+func (FFmpegConverter) WebpToMp4(ctx context.Context, webpPath string) (string, int, error) {
+	base := filepath.Join("media", fmt.Sprintf("frames_%d", time.Now().UnixNano()))
+	mp4Path := base + ".mp4"
+
+	data, err := os.ReadFile(webpPath)
+	if err != nil {
+		return mp4Path, 0, err
+	}
+	if !IsAnimatedWebp(data) {
+		return mp4Path, 0, ErrorNotAnimated
+	}
+
+	frames, durations, err := DecodeWebpFrames(data)
+	if err != nil {
+		return mp4Path, 0, err
+	}
+
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return mp4Path, 0, err
+	}
+	defer os.RemoveAll(base)
+
+	var list strings.Builder
+	var total time.Duration
+	for i, frame := range frames {
+		if IsCanceledGoroutine(ctx) {
+			return mp4Path, 0, context.Canceled
 		}
 
-		if strings.Contains(err.Error(), "exit status 1") {
-			return webpPath, context.Canceled
+		flat := image.NewRGBA(frame.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		framePath := filepath.Join(base, fmt.Sprintf("%05d.png", i))
+		if err := writePng(framePath, flat); err != nil {
+			return mp4Path, 0, err
 		}
 
-		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		absPath, _ := filepath.Abs(framePath)
+		fmt.Fprintf(&list, "file '%s'\nduration %.3f\n", absPath, durations[i].Seconds())
+		total += durations[i]
+	}
+	// The concat demuxer ignores the duration of the last entry unless the
+	// file is listed once more.
+	absPath, _ := filepath.Abs(filepath.Join(base, fmt.Sprintf("%05d.png", len(frames)-1)))
+	fmt.Fprintf(&list, "file '%s'\n", absPath)
+
+	listPath := filepath.Join(base, "frames.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return mp4Path, 0, err
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-f", "concat", "-safe", "0", "-i", listPath,
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p",
+		"-c:v", "libx264", "-movflags", "+faststart", "-an",
+		"-y", mp4Path,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
 			fmt.Println("FFmpeg failed:", stderr.String())
 		}
-
-		return webpPath, err
+		return mp4Path, 0, err
 	}
 
-	info, err := os.Stat(webpPath)
-	if err == nil && info.Size() <= 1024*1024 {
-		return webpPath, nil
+	return mp4Path, int(math.Ceil(total.Seconds())), nil
+}
+
+func writePng(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	return webpPath, ErrorNotUnder1MB
+	return png.Encode(file, img)
 }
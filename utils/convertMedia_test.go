@@ -0,0 +1,344 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const squareCrop = `crop=min(iw\,ih):min(iw\,ih)`
+
+func TestBuildFFmpegArgs(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+
+	tests := []struct {
+		name   string
+		opt    StickerOptions
+		bg     *color.NRGBA
+		flag   string // -vf or -filter_complex
+		filter string
+
+		seek     string // -ss before the input
+		duration string // -t after the inputs
+	}{
+		{
+			name:   "static center crop",
+			opt:    StickerOptions{},
+			flag:   "-vf",
+			filter: squareCrop + ",scale=512:512",
+		},
+		{
+			name:   "crop up",
+			opt:    StickerOptions{Direction: "up"},
+			flag:   "-vf",
+			filter: squareCrop + `:0:round((ih-min(iw\,ih))*(1-0.000000)),scale=512:512`,
+		},
+		{
+			name:   "crop down",
+			opt:    StickerOptions{Direction: "down"},
+			flag:   "-vf",
+			filter: squareCrop + `:0:round((ih-min(iw\,ih))*0.000000),scale=512:512`,
+		},
+		{
+			name:   "crop left with offset",
+			opt:    StickerOptions{Direction: "left-20"},
+			flag:   "-vf",
+			filter: squareCrop + `:round((iw-min(iw\,ih))*0.200000):0,scale=512:512`,
+		},
+		{
+			name:   "crop right with offset",
+			opt:    StickerOptions{Direction: "right-50"},
+			flag:   "-vf",
+			filter: squareCrop + `:round((iw-min(iw\,ih))*(1-0.500000)):0,scale=512:512`,
+		},
+		{
+			name:   "unknown direction falls back to center",
+			opt:    StickerOptions{Direction: "sideways"},
+			flag:   "-vf",
+			filter: squareCrop + ",scale=512:512",
+		},
+		{
+			name:   "nocrop pads to a transparent square",
+			opt:    StickerOptions{NoCrop: true},
+			flag:   "-vf",
+			filter: "scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000@0",
+		},
+		{
+			name:   "nocrop with reduced size",
+			opt:    StickerOptions{NoCrop: true, Size: 256},
+			flag:   "-vf",
+			filter: "scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000@0,scale=256:256,scale=512:512",
+		},
+		{
+			name:     "animated uses fps and the max duration",
+			opt:      StickerOptions{IsAnimated: true, FPS: 10},
+			flag:     "-vf",
+			filter:   "fps=10," + squareCrop + ",scale=512:512",
+			duration: "30",
+		},
+		{
+			name:   "static ignores fps and time range",
+			opt:    StickerOptions{FPS: 10, StartTime: "00:05"},
+			flag:   "-vf",
+			filter: squareCrop + ",scale=512:512",
+		},
+		{
+			name:     "start only",
+			opt:      StickerOptions{IsAnimated: true, StartTime: "00:05"},
+			flag:     "-vf",
+			filter:   "fps=15," + squareCrop + ",scale=512:512",
+			seek:     "5",
+			duration: "30",
+		},
+		{
+			name:     "start and end",
+			opt:      StickerOptions{IsAnimated: true, StartTime: "01:05", EndTime: "01:12"},
+			flag:     "-vf",
+			filter:   "fps=15," + squareCrop + ",scale=512:512",
+			seek:     "65",
+			duration: "7",
+		},
+		{
+			name:     "speed shortens the output of the same source range",
+			opt:      StickerOptions{IsAnimated: true, Speed: 2},
+			flag:     "-vf",
+			filter:   "setpts=PTS/2,fps=15," + squareCrop + ",scale=512:512",
+			duration: "15",
+		},
+		{
+			name:   "reverse",
+			opt:    StickerOptions{IsAnimated: true, Reverse: true, MaxDuration: 10},
+			flag:   "-vf",
+			filter: "trim=duration=10,setpts=PTS-STARTPTS,fps=15," + squareCrop + ",scale=512:512,reverse",
+		},
+		{
+			name:   "reverse caps the buffered clip",
+			opt:    StickerOptions{IsAnimated: true, Reverse: true},
+			flag:   "-vf",
+			filter: "trim=duration=10,setpts=PTS-STARTPTS,fps=15," + squareCrop + ",scale=512:512,reverse",
+		},
+		{
+			name:   "reverse caps an explicit end",
+			opt:    StickerOptions{IsAnimated: true, Reverse: true, StartTime: "00:05", EndTime: "01:00", FPS: 30},
+			flag:   "-vf",
+			filter: "trim=duration=5,setpts=PTS-STARTPTS,fps=30," + squareCrop + ",scale=512:512,reverse",
+			seek:   "5",
+		},
+		{
+			name:   "slowed boomerang buffers fewer source seconds",
+			opt:    StickerOptions{IsAnimated: true, Boomerang: true, Speed: 0.5},
+			flag:   "-filter_complex",
+			filter: "[0:v]trim=duration=5,setpts=PTS-STARTPTS,setpts=PTS/0.5,fps=15," + squareCrop + ",scale=512:512," + boomerangFilter,
+		},
+		{
+			name:   "boomerang needs a filter graph",
+			opt:    StickerOptions{IsAnimated: true, Boomerang: true, MaxDuration: 10},
+			flag:   "-filter_complex",
+			filter: "[0:v]trim=duration=10,setpts=PTS-STARTPTS,fps=15," + squareCrop + ",scale=512:512," + boomerangFilter,
+		},
+		{
+			name:   "overlay",
+			opt:    StickerOptions{Overlay: "caption.png"},
+			flag:   "-filter_complex",
+			filter: "[0:v]" + squareCrop + ",scale=512:512,format=rgba[base];[base][1:v]overlay=0:0",
+		},
+		{
+			name:   "effects follow the crop",
+			opt:    StickerOptions{Mirror: true, Rotate: 90, Grayscale: true},
+			flag:   "-vf",
+			filter: squareCrop + ",scale=512:512,hflip,transpose=clock,hue=s=0",
+		},
+		{
+			name:   "background key comes before the crop",
+			opt:    StickerOptions{RemoveBackground: true},
+			bg:     &red,
+			flag:   "-vf",
+			filter: "format=rgba,colorkey=color=0xFF0000:similarity=0.15:blend=0.05," + squareCrop + ",scale=512:512",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := tt.opt
+			applyWebpDefaults(&opt)
+			args := BuildFFmpegArgs("in.mp4", "out.webp", &opt, tt.bg)
+
+			i := slices.Index(args, tt.flag)
+			if i < 0 || i+1 >= len(args) {
+				t.Fatalf("no %s in %q", tt.flag, args)
+			}
+			if got := args[i+1]; got != tt.filter {
+				t.Errorf("filter\n got %s\nwant %s", got, tt.filter)
+			}
+
+			var wantStart []string
+			if tt.seek != "" {
+				wantStart = append(wantStart, "-ss", tt.seek)
+			}
+			wantStart = append(wantStart, "-i", "in.mp4")
+			if !slices.Equal(args[:len(wantStart)], wantStart) {
+				t.Errorf("args start with %q, want %q", args[:len(wantStart)], wantStart)
+			}
+			duration := ""
+			if i := slices.Index(args, "-t"); i >= 0 {
+				duration = args[i+1]
+				if i < slices.Index(args, "in.mp4") {
+					t.Errorf("-t is an input option in %q", args)
+				}
+			}
+			if duration != tt.duration {
+				t.Errorf("duration %q, want %q", duration, tt.duration)
+			}
+			if hasOverlay := slices.Contains(args, "caption.png"); hasOverlay != (opt.Overlay != "") {
+				t.Errorf("overlay input in %q", args)
+			}
+			tail := strings.Join(args[len(args)-6:], " ")
+			if want := "-quality 100 -pix_fmt rgba -y out.webp"; tail != want {
+				t.Errorf("args end with %q, want %q", tail, want)
+			}
+		})
+	}
+}
+
+func TestBuildFFmpegArgsDoesNotChangeOptions(t *testing.T) {
+	opt := StickerOptions{IsAnimated: true, StartTime: "00:05", Quality: 60, FPS: 12, Size: 512, MaxDuration: 30}
+	before := opt
+	BuildFFmpegArgs("in.mp4", "out.webp", &opt, nil)
+	if !reflect.DeepEqual(opt, before) {
+		t.Fatalf("options changed: %+v", opt)
+	}
+}
+
+func inMediaDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.Mkdir("media", 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFitWebpWithFakeConverter(t *testing.T) {
+	inMediaDir(t)
+
+	t.Run("fits at once", func(t *testing.T) {
+		fake := &FakeConverter{}
+		defer UseFakeConverter(fake)()
+
+		opt := &StickerOptions{Quality: 70}
+		_, compromises, err := FitWebp(context.Background(), "in.png", opt)
+		if err != nil || len(compromises) != 0 {
+			t.Fatalf("got %v %v", compromises, err)
+		}
+		if len(fake.Conversions) != 1 || fake.Conversions[0].Quality != 70 {
+			t.Fatalf("conversions: %+v", fake.Conversions)
+		}
+	})
+
+	t.Run("never fits", func(t *testing.T) {
+		fake := &FakeConverter{Webp: make([]byte, 2<<20), Duration: 12}
+		defer UseFakeConverter(fake)()
+
+		_, _, err := FitWebp(context.Background(), "in.mp4", &StickerOptions{IsAnimated: true, FPS: 20})
+		if !errors.Is(err, ErrorNotUnder1MB) {
+			t.Fatalf("got %v", err)
+		}
+		for _, conversion := range fake.Conversions {
+			if conversion.FPS != 20 {
+				t.Fatalf("pinned fps was lowered: %+v", conversion)
+			}
+		}
+		if len(fake.Conversions) < 2 {
+			t.Fatalf("ladder was not walked: %d conversions", len(fake.Conversions))
+		}
+	})
+}
+
+func TestConvertToWebpDetectsBackground(t *testing.T) {
+	inMediaDir(t)
+
+	frame := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for i := range frame.Pix {
+		frame.Pix[i] = 255 // white, opaque
+	}
+	fake := &FakeConverter{Frame: frame}
+	defer UseFakeConverter(fake)()
+	if err := os.WriteFile("in.png", []byte("not a webp"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ConvertToWebp(context.Background(), "in.png", &StickerOptions{RemoveBackground: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter := strings.Join(fake.Args[0], " "); !strings.Contains(filter, "colorkey=color=0xFFFFFF") {
+		t.Fatalf("background not keyed: %s", filter)
+	}
+}
+
+// fakeFFmpeg puts an ffmpeg that always fails first in PATH.
+func fakeFFmpeg(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestFFmpegFailureIsNotCancellation(t *testing.T) {
+	inMediaDir(t)
+	fakeFFmpeg(t)
+
+	_, err := FFmpegConverter{}.ConvertToWebp(context.Background(), "in.mp4", &StickerOptions{})
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Fatalf("failed ffmpeg reported as %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = FFmpegConverter{}.ConvertToWebp(ctx, "in.mp4", &StickerOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled job reported as %v", err)
+	}
+}
+
+func TestConvertToWebpOutputsAreUnique(t *testing.T) {
+	inMediaDir(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// This ffmpeg writes a byte to its output path, the last argument.
+	bin := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nprintf x > \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	seen := make(map[string]bool)
+	for range 3 {
+		webpPath, err := FFmpegConverter{}.ConvertToWebp(context.Background(), "in.mp4", &StickerOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[webpPath] || filepath.Dir(webpPath) != tmp || filepath.Ext(webpPath) != ".webp" {
+			t.Fatalf("output %s, after %v", webpPath, seen)
+		}
+		seen[webpPath] = true
+	}
+}
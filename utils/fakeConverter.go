@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// blankWebp is a 1x1 transparent WebP.
+var blankWebp = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+// FakeConverter is a MediaConverter that runs no tools, for tests. Every
+// conversion writes Webp, or a blank 1x1 WebP when it is nil, and records the
+// options and the ffmpeg arguments it would have run. Webp over 1MB makes
+// conversions fail with ErrorNotUnder1MB like the real converter.
+type FakeConverter struct {
+	sync.Mutex
+
+	Webp     []byte
+	Duration float64     // 0 makes MediaDuration return ErrorNotVideo
+	Frame    image.Image // returned by FirstFrame for media other than WebP
+	Err      error       // returned by ConvertToWebp when set
+
+	// Downloads maps the links DownloadMedia knows to their content. Other
+	// links fail with ErrorNotSupportedLink.
+	Downloads map[string][]byte
+
+	Conversions []StickerOptions
+	Args        [][]string
+	Metadata    []StickerMetadata
+	URLs        []string
+}
+
+// UseFakeConverter replaces Converter with f until cleanup is called.
+func UseFakeConverter(f *FakeConverter) (cleanup func()) {
+	previous := Converter
+	Converter = f
+	return func() { Converter = previous }
+}
+
+func (f *FakeConverter) ConvertToWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (string, error) {
+	webpPath := filepath.Join("media", fmt.Sprintf("fake_%d.webp", time.Now().UnixNano()))
+	args, err := webpArgs(ctx, mediaPath, webpPath, opt)
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.Conversions = append(f.Conversions, *opt)
+	if err != nil {
+		return webpPath, err
+	}
+	f.Args = append(f.Args, args)
+	if f.Err != nil {
+		return webpPath, f.Err
+	}
+	if IsCanceledGoroutine(ctx) {
+		return webpPath, ctx.Err()
+	}
+
+	data := f.Webp
+	if data == nil {
+		data = blankWebp
+	}
+	if err := os.WriteFile(webpPath, data, 0644); err != nil {
+		return webpPath, err
+	}
+	return webpPath, checkWebpSize(webpPath)
+}
+
+// WebpToMp4 decodes the sticker like the real converter and writes a
+// placeholder file instead of the video.
+func (f *FakeConverter) WebpToMp4(ctx context.Context, webpPath string) (string, int, error) {
+	mp4Path := filepath.Join("media", fmt.Sprintf("fake_%d.mp4", time.Now().UnixNano()))
+
+	data, err := os.ReadFile(webpPath)
+	if err != nil {
+		return mp4Path, 0, err
+	}
+	if !IsAnimatedWebp(data) {
+		return mp4Path, 0, ErrorNotAnimated
+	}
+	_, durations, err := DecodeWebpFrames(data)
+	if err != nil {
+		return mp4Path, 0, err
+	}
+
+	var total time.Duration
+	for _, duration := range durations {
+		total += duration
+	}
+	seconds := int((total + time.Second - 1) / time.Second)
+	return mp4Path, seconds, os.WriteFile(mp4Path, []byte("fake mp4"), 0644)
+}
+
+func (f *FakeConverter) FirstFrame(ctx context.Context, mediaPath string, start string) (image.Image, error) {
+	if f.Frame == nil {
+		return nil, ErrorNotVideo
+	}
+	return f.Frame, nil
+}
+
+func (f *FakeConverter) MediaDuration(filePath string) (float64, error) {
+	if f.Duration == 0 {
+		return 0, ErrorNotVideo
+	}
+	return f.Duration, nil
+}
+
+// WriteWebpExif sets the EXIF like the real converter, since that needs no
+// tools, and records the metadata.
+func (f *FakeConverter) WriteWebpExif(ctx context.Context, inputPath string, metadata StickerMetadata) (string, error) {
+	f.Lock()
+	f.Metadata = append(f.Metadata, metadata)
+	f.Unlock()
+
+	return FFmpegConverter{}.WriteWebpExif(ctx, inputPath, metadata)
+}
+
+func (f *FakeConverter) DownloadMedia(ctx context.Context, url string) (string, string, error) {
+	mediaPath := filepath.Join("media", fmt.Sprintf("fake_%d", time.Now().UnixNano()))
+
+	f.Lock()
+	f.URLs = append(f.URLs, url)
+	data, exists := f.Downloads[url]
+	f.Unlock()
+
+	if !exists {
+		return mediaPath, "", ErrorNotSupportedLink
+	}
+	if err := os.WriteFile(mediaPath, data, 0644); err != nil {
+		return mediaPath, "", err
+	}
+
+	mimeType, err := GetMimeType(mediaPath)
+	if err != nil {
+		return mediaPath, "", err
+	}
+	return mediaPath, mimeType, nil
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+)
+
+// Compromise records a setting FitWebp had to lower to get under 1MB.
+type Compromise struct {
+	Setting string // "quality", "fps", "size" or "duration"
+	From    int
+	To      int
+}
+
+// fitStep is one rung of the fitting ladder. Each rung is smaller than the
+// previous one; settings the user pinned are left untouched.
+type fitStep struct {
+	Quality  int
+	FPS      int
+	Size     int
+	Duration int
+}
+
+var staticLadder = []fitStep{
+	{Quality: 80, Size: 512},
+	{Quality: 60, Size: 512},
+	{Quality: 40, Size: 512},
+	{Quality: 40, Size: 384},
+	{Quality: 25, Size: 256},
+}
+
+var animatedLadder = []fitStep{
+	{Quality: 75, FPS: 15, Size: 512, Duration: 30},
+	{Quality: 60, FPS: 12, Size: 512, Duration: 30},
+	{Quality: 50, FPS: 10, Size: 512, Duration: 20},
+	{Quality: 40, FPS: 10, Size: 384, Duration: 15},
+	{Quality: 30, FPS: 8, Size: 384, Duration: 10},
+	{Quality: 25, FPS: 6, Size: 256, Duration: 8},
+	{Quality: 20, FPS: 5, Size: 256, Duration: 5},
+}
+
+// FitWebp converts like ConvertToWebp, but when the result is over 1MB it
+// walks down the fitting ladder until a conversion fits. Quality, FPS and the
+// time range are only lowered when the user did not set them. It returns the
+// settings that were lowered, or ErrorNotUnder1MB when nothing fits.
+func FitWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (string, []Compromise, error) {
+	baseline := *opt
+	webpPath, err := ConvertToWebp(ctx, mediaPath, &baseline)
+	if !errors.Is(err, ErrorNotUnder1MB) {
+		*opt = baseline
+		return webpPath, nil, err
+	}
+
+	ladder := staticLadder
+	mediaDuration := 0
+	if opt.IsAnimated {
+		ladder = animatedLadder
+		mediaDuration = baseline.MaxDuration
+		if duration, err := GetMediaDuration(mediaPath); err == nil {
+			remaining := int(math.Ceil(duration - ParseTimeFromString(opt.StartTime)))
+			if remaining > 0 && remaining < mediaDuration {
+				mediaDuration = remaining
+			}
+		}
+	}
+
+	last := baseline
+	for _, step := range ladder {
+		attempt := *opt
+		attempt.Size = step.Size
+		attempt.Quality = pick(opt.Quality, step.Quality, baseline.Quality)
+		if opt.IsAnimated {
+			attempt.FPS = pick(opt.FPS, step.FPS, baseline.FPS)
+			if opt.EndTime == "" && step.Duration < mediaDuration {
+				attempt.MaxDuration = step.Duration
+			} else {
+				attempt.MaxDuration = baseline.MaxDuration
+			}
+		}
+		if attempt.Quality == last.Quality && attempt.FPS == last.FPS &&
+			attempt.Size == last.Size && attempt.MaxDuration == last.MaxDuration {
+			continue
+		}
+
+		os.Remove(webpPath)
+		webpPath, err = ConvertToWebp(ctx, mediaPath, &attempt)
+		last = attempt
+		if errors.Is(err, ErrorNotUnder1MB) {
+			continue
+		}
+		if err != nil {
+			return webpPath, nil, err
+		}
+
+		*opt = attempt
+		return webpPath, compromises(baseline, attempt, mediaDuration), nil
+	}
+
+	return webpPath, nil, ErrorNotUnder1MB
+}
+
+// pick keeps a pinned value, otherwise takes the step's value as long as it
+// does not raise the baseline.
+func pick(pinned, step, baseline int) int {
+	if pinned != 0 {
+		return pinned
+	}
+	return min(step, baseline)
+}
+
+func compromises(from, to StickerOptions, mediaDuration int) []Compromise {
+	var result []Compromise
+	if to.Quality < from.Quality {
+		result = append(result, Compromise{"quality", from.Quality, to.Quality})
+	}
+	if to.IsAnimated && to.FPS < from.FPS {
+		result = append(result, Compromise{"fps", from.FPS, to.FPS})
+	}
+	if to.Size < from.Size {
+		result = append(result, Compromise{"size", from.Size, to.Size})
+	}
+	if to.IsAnimated && to.EndTime == "" && to.MaxDuration < mediaDuration {
+		result = append(result, Compromise{"duration", mediaDuration, to.MaxDuration})
+	}
+	return result
+}
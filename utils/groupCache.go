@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// GroupCacheType keeps the participant lists of joined groups in memory so
+// role resolution is a map lookup instead of a GetGroupInfo round-trip. It is
+// filled from GetJoinedGroups, patched by group events and lazily refreshed
+// once an entry is older than the TTL. Participants are keyed by their phone
+// number JID whenever the LID mapping is known.
+type GroupCacheType struct {
+	sync.RWMutex
+	Groups map[string]*GroupEntry
+
+	// Failures holds when GetGroupInfo last failed for a group, so that a
+	// group the bot cannot read is not fetched again on every message.
+	Failures map[string]time.Time
+}
+
+// groupFailureTTL is how long a failed GetGroupInfo is remembered.
+const groupFailureTTL = 30 * time.Second
+
+// GroupInfoGetter is the part of the WhatsApp client the cache fetches
+// groups with.
+type GroupInfoGetter interface {
+	GetGroupInfo(jid waTypes.JID) (*waTypes.GroupInfo, error)
+}
+
+type GroupEntry struct {
+	Name         string
+	Participants map[string]waTypes.GroupParticipant
+	FetchedAt    time.Time
+}
+
+var GroupCache = &GroupCacheType{
+	Groups:   make(map[string]*GroupEntry),
+	Failures: make(map[string]time.Time),
+}
+
+func groupCacheTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("GROUP_CACHE_TTL"))
+	if err != nil || minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func (gc *GroupCacheType) Load(client *whatsmeow.Client) error {
+	groups, err := client.GetJoinedGroups()
+	if err != nil {
+		return err
+	}
+
+	gc.Lock()
+	defer gc.Unlock()
+
+	gc.Groups = make(map[string]*GroupEntry, len(groups))
+	gc.Failures = make(map[string]time.Time)
+	for _, group := range groups {
+		gc.Groups[group.JID.String()] = newGroupEntry(group)
+	}
+
+	fmt.Printf("Group cache loaded with %d groups\n", len(groups))
+	return nil
+}
+
+func (gc *GroupCacheType) Count() int {
+	gc.RLock()
+	defer gc.RUnlock()
+
+	return len(gc.Groups)
+}
+
+func (gc *GroupCacheType) Set(info *waTypes.GroupInfo) {
+	gc.Lock()
+	defer gc.Unlock()
+
+	gc.Groups[info.JID.String()] = newGroupEntry(info)
+	delete(gc.Failures, info.JID.String())
+}
+
+func (gc *GroupCacheType) Remove(groupJID waTypes.JID) {
+	gc.Lock()
+	defer gc.Unlock()
+
+	delete(gc.Groups, groupJID.String())
+}
+
+// ApplyChange patches a cached group with a participant change notification.
+func (gc *GroupCacheType) ApplyChange(evt *events.GroupInfo) {
+	gc.Lock()
+	defer gc.Unlock()
+
+	entry, exists := gc.Groups[evt.JID.String()]
+	if !exists {
+		return
+	}
+
+	if evt.Name != nil {
+		entry.Name = evt.Name.Name
+	}
+	for _, jid := range evt.Join {
+		entry.Participants[LIDMap.ToPN(jid).String()] = waTypes.GroupParticipant{JID: jid.ToNonAD()}
+	}
+	for _, jid := range evt.Leave {
+		delete(entry.Participants, LIDMap.ToPN(jid).String())
+	}
+	for _, jid := range evt.Promote {
+		if participant, ok := entry.Participants[LIDMap.ToPN(jid).String()]; ok {
+			participant.IsAdmin = true
+			entry.Participants[LIDMap.ToPN(jid).String()] = participant
+		}
+	}
+	for _, jid := range evt.Demote {
+		if participant, ok := entry.Participants[LIDMap.ToPN(jid).String()]; ok {
+			participant.IsAdmin = false
+			entry.Participants[LIDMap.ToPN(jid).String()] = participant
+		}
+	}
+}
+
+// Get returns the cached group, fetching it first when it is missing or
+// older than the TTL. A stale entry is still served if the refresh fails, and
+// a failed fetch is not retried for groupFailureTTL.
+func (gc *GroupCacheType) Get(client GroupInfoGetter, groupJID waTypes.JID) (*GroupEntry, bool) {
+	gc.RLock()
+	entry, exists := gc.Groups[groupJID.String()]
+	failedAt, failed := gc.Failures[groupJID.String()]
+	gc.RUnlock()
+
+	if exists && time.Since(entry.FetchedAt) < groupCacheTTL() {
+		return entry, true
+	}
+	if failed && time.Since(failedAt) < groupFailureTTL {
+		return entry, exists
+	}
+
+	info, err := client.GetGroupInfo(groupJID)
+	if err != nil {
+		fmt.Println("Failed to get group info for", groupJID.String(), ":", err)
+		gc.Lock()
+		gc.Failures[groupJID.String()] = time.Now()
+		gc.Unlock()
+		return entry, exists
+	}
+
+	gc.Set(info)
+
+	gc.RLock()
+	defer gc.RUnlock()
+	entry = gc.Groups[groupJID.String()]
+	return entry, true
+}
+
+func (gc *GroupCacheType) GetParticipant(client GroupInfoGetter, groupJID, userJID waTypes.JID) (waTypes.GroupParticipant, bool) {
+	entry, exists := gc.Get(client, groupJID)
+	if !exists {
+		return waTypes.GroupParticipant{}, false
+	}
+
+	gc.RLock()
+	defer gc.RUnlock()
+	pn := LIDMap.ToPN(userJID)
+	if participant, found := entry.Participants[pn.String()]; found {
+		return participant, true
+	}
+	if lid, exists := LIDMap.GetLID(pn); exists {
+		participant, found := entry.Participants[lid.String()]
+		return participant, found
+	}
+	return waTypes.GroupParticipant{}, false
+}
+
+func (gc *GroupCacheType) IsMember(client GroupInfoGetter, groupJID, userJID waTypes.JID) bool {
+	_, found := gc.GetParticipant(client, groupJID, userJID)
+	return found
+}
+
+func newGroupEntry(info *waTypes.GroupInfo) *GroupEntry {
+	entry := &GroupEntry{
+		Name:         info.Name,
+		Participants: make(map[string]waTypes.GroupParticipant, len(info.Participants)),
+		FetchedAt:    time.Now(),
+	}
+	for _, participant := range info.Participants {
+		if !participant.LID.IsEmpty() && participant.JID.Server == waTypes.DefaultUserServer {
+			LIDMap.Put(participant.LID, participant.JID)
+		}
+		entry.Participants[LIDMap.ToPN(participant.JID).String()] = participant
+	}
+	return entry
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+type fakeGroupGetter struct {
+	calls int
+	err   error
+}
+
+func (f *fakeGroupGetter) GetGroupInfo(jid waTypes.JID) (*waTypes.GroupInfo, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	member := waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+	return &waTypes.GroupInfo{
+		JID:          jid,
+		GroupName:    waTypes.GroupName{Name: "Test"},
+		Participants: []waTypes.GroupParticipant{{JID: member}},
+	}, nil
+}
+
+func newTestGroupCache() *GroupCacheType {
+	return &GroupCacheType{
+		Groups:   make(map[string]*GroupEntry),
+		Failures: make(map[string]time.Time),
+	}
+}
+
+func TestGroupCacheRemembersFailures(t *testing.T) {
+	gc := newTestGroupCache()
+	group := waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	client := &fakeGroupGetter{err: errors.New("forbidden")}
+
+	for range 3 {
+		if _, exists := gc.Get(client, group); exists {
+			t.Fatal("failed group reported as cached")
+		}
+	}
+	if client.calls != 1 {
+		t.Fatalf("fetched %d times within the failure TTL, want once", client.calls)
+	}
+
+	// Once the failure is old enough the group is fetched again.
+	gc.Failures[group.String()] = time.Now().Add(-groupFailureTTL)
+	client.err = nil
+	entry, exists := gc.Get(client, group)
+	if !exists || entry.Name != "Test" || client.calls != 2 {
+		t.Fatalf("got %v %v after %d calls", entry, exists, client.calls)
+	}
+	if _, failed := gc.Failures[group.String()]; failed {
+		t.Error("failure not cleared after a successful fetch")
+	}
+}
+
+func TestGroupCacheServesStaleEntryOnFailure(t *testing.T) {
+	gc := newTestGroupCache()
+	group := waTypes.NewJID("120363000000000001", waTypes.GroupServer)
+	member := waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+	client := &fakeGroupGetter{}
+
+	if !gc.IsMember(client, group, member) {
+		t.Fatal("member not found")
+	}
+	gc.Groups[group.String()].FetchedAt = time.Now().Add(-groupCacheTTL())
+
+	client.err = errors.New("timeout")
+	for range 2 {
+		if !gc.IsMember(client, group, member) {
+			t.Fatal("stale entry not served")
+		}
+	}
+	if client.calls != 2 {
+		t.Fatalf("fetched %d times, want one fetch and one failed refresh", client.calls)
+	}
+}
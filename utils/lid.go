@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+
+	"wa-bot/storage"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// LIDMapType maps linked identities (@lid JIDs) to phone number JIDs. It is
+// fed by group participant lists and history sync, persisted in the bot
+// database and fully held in memory so lookups never touch the network.
+type LIDMapType struct {
+	sync.RWMutex
+	LIDToPN map[string]waTypes.JID
+	PNToLID map[string]waTypes.JID
+}
+
+var LIDMap = &LIDMapType{
+	LIDToPN: make(map[string]waTypes.JID),
+	PNToLID: make(map[string]waTypes.JID),
+}
+
+func (m *LIDMapType) Load() error {
+	mappings, err := storage.GetLIDMappings()
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for lid, pn := range mappings {
+		lidJID, lidErr := waTypes.ParseJID(lid)
+		pnJID, pnErr := waTypes.ParseJID(pn)
+		if lidErr != nil || pnErr != nil {
+			continue
+		}
+		m.LIDToPN[lidJID.String()] = pnJID
+		m.PNToLID[pnJID.String()] = lidJID
+	}
+
+	return nil
+}
+
+func (m *LIDMapType) Put(lid, pn waTypes.JID) {
+	lid, pn = lid.ToNonAD(), pn.ToNonAD()
+	if lid.Server != waTypes.HiddenUserServer || pn.Server != waTypes.DefaultUserServer {
+		return
+	}
+
+	m.Lock()
+	known, exists := m.LIDToPN[lid.String()]
+	m.LIDToPN[lid.String()] = pn
+	m.PNToLID[pn.String()] = lid
+	m.Unlock()
+
+	if exists && known == pn {
+		return
+	}
+
+	if err := storage.PutLIDMapping(lid.String(), pn.String()); err != nil {
+		fmt.Println("Failed to store LID mapping for", lid.String(), ":", err)
+	}
+}
+
+// ToPN returns the phone number JID for jid when it is a known LID, and jid
+// itself otherwise.
+func (m *LIDMapType) ToPN(jid waTypes.JID) waTypes.JID {
+	jid = jid.ToNonAD()
+	if jid.Server != waTypes.HiddenUserServer {
+		return jid
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	if pn, exists := m.LIDToPN[jid.String()]; exists {
+		return pn
+	}
+	return jid
+}
+
+func (m *LIDMapType) GetLID(pn waTypes.JID) (waTypes.JID, bool) {
+	m.RLock()
+	defer m.RUnlock()
+
+	lid, exists := m.PNToLID[pn.ToNonAD().String()]
+	return lid, exists
+}
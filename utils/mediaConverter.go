@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaConverter does the work of the sticker pipeline that needs external
+// tools. ConvertToWebp, ConvertWebpToMp4, DetectBackgroundColor,
+// GetMediaDuration, WriteWebpExifFile and DownloadMediaFromURL go through
+// Converter, so replacing it with a FakeConverter runs the pipeline without
+// ffmpeg, ffprobe, yt-dlp or gallery-dl installed.
+type MediaConverter interface {
+	ConvertToWebp(ctx context.Context, mediaPath string, opt *StickerOptions) (string, error)
+	WebpToMp4(ctx context.Context, webpPath string) (string, int, error)
+	FirstFrame(ctx context.Context, mediaPath string, start string) (image.Image, error)
+	MediaDuration(filePath string) (float64, error)
+	WriteWebpExif(ctx context.Context, inputPath string, metadata StickerMetadata) (string, error)
+	DownloadMedia(ctx context.Context, url string) (string, string, error)
+}
+
+var Converter MediaConverter = FFmpegConverter{}
+
+// FFmpegConverter converts with ffmpeg, reads durations with ffprobe and
+// downloads links with yt-dlp or gallery-dl.
+type FFmpegConverter struct{}
+
+func (FFmpegConverter) MediaDuration(filePath string) (float64, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1", filePath)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	durationStr := strings.TrimSpace(string(output))
+	duration, err := strconv.ParseFloat(durationStr, 64)
+	if err != nil {
+		return 0, ErrorNotVideo
+	}
+
+	return duration, nil
+}
+
+func (FFmpegConverter) WriteWebpExif(ctx context.Context, inputPath string, metadata StickerMetadata) (string, error) {
+	if IsCanceledGoroutine(ctx) {
+		return "", ctx.Err()
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return "", err
+	}
+
+	exif, err := BuildStickerExif(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := SetWebpExif(data, exif)
+	if err != nil {
+		return "", err
+	}
+
+	outputPath := filepath.Join("media", fmt.Sprintf("%d_convert_output.webp", time.Now().UnixNano()))
+	if err := os.WriteFile(outputPath, output, 0644); err != nil {
+		return "", err
+	}
+
+	return outputPath, nil
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// QuoteBubble is the content of a !qc sticker. A nil Avatar draws a
+// placeholder with the first letter of Name.
+type QuoteBubble struct {
+	Name   string
+	Text   string
+	Seed   string // picks the name color, usually the sender's JID
+	Avatar image.Image
+}
+
+var (
+	regularFontOnce sync.Once
+	regularFont     *opentype.Font
+	regularFontErr  error
+)
+
+func loadRegularFont() (*opentype.Font, error) {
+	regularFontOnce.Do(func() {
+		regularFont, regularFontErr = opentype.Parse(goregular.TTF)
+	})
+	return regularFont, regularFontErr
+}
+
+// nameColors are the sender name colors used in WhatsApp group chats.
+var nameColors = []color.NRGBA{
+	{0xE5, 0x39, 0x35, 0xFF},
+	{0x1E, 0x88, 0xE5, 0xFF},
+	{0x43, 0xA0, 0x47, 0xFF},
+	{0xFB, 0x8C, 0x00, 0xFF},
+	{0x8E, 0x24, 0xAA, 0xFF},
+	{0x00, 0x89, 0x7B, 0xFF},
+	{0xD8, 0x1B, 0x60, 0xFF},
+	{0x6D, 0x4C, 0x41, 0xFF},
+	{0x39, 0x49, 0xAB, 0xFF},
+	{0x7C, 0xB3, 0x42, 0xFF},
+}
+
+func nameColor(seed string) color.NRGBA {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return nameColors[h.Sum32()%uint32(len(nameColors))]
+}
+
+var (
+	bubbleColor = color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	textColor   = color.NRGBA{0x11, 0x1B, 0x21, 0xFF}
+)
+
+// RenderQuotePng renders a chat bubble with the avatar, name and text as a
+// transparent 512x512 PNG.
+func RenderQuotePng(q QuoteBubble) (string, error) {
+	pngPath := filepath.Join("media", fmt.Sprintf("qc_%d.png", time.Now().UnixNano()))
+
+	bold, err := loadBoldFont()
+	if err != nil {
+		return pngPath, err
+	}
+	regular, err := loadRegularFont()
+	if err != nil {
+		return pngPath, err
+	}
+
+	text := strings.TrimSpace(renderableText(regular, q.Text))
+	if text == "" {
+		return pngPath, ErrorNoRenderableText
+	}
+	name := strings.TrimSpace(renderableText(bold, q.Name))
+
+	const (
+		margin     = 8
+		avatarSize = 72
+		padding    = 16
+		gap        = 6
+		bubbleX    = margin + avatarSize + margin
+		maxContent = 512 - bubbleX - margin - 2*padding
+	)
+
+	nameFace, err := newFace(bold, 30)
+	if err != nil {
+		return pngPath, err
+	}
+	defer nameFace.Close()
+	name = truncateText(nameFace, name, maxContent)
+	nameHeight := nameFace.Metrics().Height.Ceil()
+
+	maxTextHeight := 512 - 2*margin - 2*padding - nameHeight - gap
+	textFace, lines, err := fitText(regular, text, maxContent, maxTextHeight, 44, 16)
+	if err != nil {
+		return pngPath, err
+	}
+	defer textFace.Close()
+	lineHeight := textFace.Metrics().Height.Ceil()
+
+	contentWidth := font.MeasureString(nameFace, name).Ceil()
+	for _, line := range lines {
+		contentWidth = max(contentWidth, font.MeasureString(textFace, line).Ceil())
+	}
+	contentWidth = min(contentWidth, maxContent)
+
+	bubbleWidth := contentWidth + 2*padding
+	bubbleHeight := min(2*padding+nameHeight+gap+lineHeight*len(lines), 512-2*margin)
+	bubbleY := (512 - bubbleHeight) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, 512, 512))
+
+	bubble := image.Rect(bubbleX, bubbleY, bubbleX+bubbleWidth, bubbleY+bubbleHeight)
+	draw.DrawMask(img, bubble, image.NewUniform(bubbleColor), image.Point{},
+		roundedRectMask(bubbleWidth, bubbleHeight, 24), image.Point{}, draw.Over)
+
+	y := bubbleY + padding
+	drawTextLeft(img, image.Pt(bubbleX+padding, y), nameFace, []string{name}, nameColor(q.Seed))
+	drawTextLeft(img, image.Pt(bubbleX+padding, y+nameHeight+gap), textFace, lines, textColor)
+
+	avatarRect := image.Rect(margin, bubble.Max.Y-avatarSize, margin+avatarSize, bubble.Max.Y)
+	drawAvatar(img, avatarRect, q, bold)
+
+	return pngPath, writePng(pngPath, img)
+}
+
+func drawTextLeft(dst draw.Image, at image.Point, face font.Face, lines []string, c color.Color) {
+	metrics := face.Metrics()
+	drawer := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+	for i, line := range lines {
+		drawer.Dot = fixed.P(at.X, at.Y+i*metrics.Height.Ceil()+metrics.Ascent.Ceil())
+		drawer.DrawString(line)
+	}
+}
+
+// truncateText shortens text with an ellipsis until it fits maxWidth.
+func truncateText(face font.Face, text string, maxWidth int) string {
+	if font.MeasureString(face, text).Ceil() <= maxWidth {
+		return text
+	}
+	for text != "" {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+		if font.MeasureString(face, text+"…").Ceil() <= maxWidth {
+			return text + "…"
+		}
+	}
+	return ""
+}
+
+func drawAvatar(dst draw.Image, rect image.Rectangle, q QuoteBubble, bold *opentype.Font) {
+	size := rect.Dx()
+	mask := roundedRectMask(size, size, size/2)
+
+	if q.Avatar != nil {
+		scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+		xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), q.Avatar, q.Avatar.Bounds(), xdraw.Src, nil)
+		draw.DrawMask(dst, rect, scaled, image.Point{}, mask, image.Point{}, draw.Over)
+		return
+	}
+
+	draw.DrawMask(dst, rect, image.NewUniform(nameColor(q.Seed)), image.Point{}, mask, image.Point{}, draw.Over)
+
+	initial, _ := utf8.DecodeRuneInString(strings.ToUpper(strings.TrimSpace(renderableText(bold, q.Name))))
+	if initial == utf8.RuneError {
+		initial = '?'
+	}
+	face, err := newFace(bold, float64(size)/2)
+	if err != nil {
+		return
+	}
+	defer face.Close()
+
+	letter := image.NewAlpha(rect)
+	drawTextLines(letter, rect, face, []string{string(initial)})
+	draw.DrawMask(dst, rect, image.White, image.Point{}, letter, rect.Min, draw.Over)
+}
+
+// roundedRectMask returns a w x h mask with corners of the given radius. A
+// radius of half the size gives a circle.
+func roundedRectMask(w, h, radius int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	radius = min(radius, w/2, h/2)
+	r := float64(radius)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cx, cy := float64(x)+0.5, float64(y)+0.5
+			dx := max(r-cx, cx-(float64(w)-r), 0)
+			dy := max(r-cy, cy-(float64(h)-r), 0)
+			if dx == 0 || dy == 0 {
+				mask.SetAlpha(x, y, color.Alpha{255})
+				continue
+			}
+
+			// One pixel of anti-aliasing along the curve.
+			coverage := r + 0.5 - math.Sqrt(dx*dx+dy*dy)
+			if coverage > 0 {
+				mask.SetAlpha(x, y, color.Alpha{uint8(min(coverage, 1) * 255)})
+			}
+		}
+	}
+	return mask
+}
+
+// FetchImage downloads and decodes a JPEG or PNG image.
+func FetchImage(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch image, status: %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
@@ -1,32 +1,66 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
+	"wa-bot/storage"
+
 	"go.mau.fi/whatsmeow"
 	waTypes "go.mau.fi/whatsmeow/types"
 )
 
-func AssignRole(client *whatsmeow.Client, isFromGroup bool, senderJID waTypes.JID) string {
-	owner := os.Getenv("OWNER_JID")
-	if senderJID.String() == owner {
+var Roles = []string{"OWNER", "ADMIN", "USER", "COMMON"}
+
+// IsOwner reports whether jid is listed in OWNER_JID, which may hold several
+// comma separated JIDs.
+func IsOwner(jid waTypes.JID) bool {
+	for _, owner := range strings.Split(os.Getenv("OWNER_JID"), ",") {
+		if strings.TrimSpace(owner) == jid.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOwnerRole reports whether jid is an owner, either from OWNER_JID or
+// through an OWNER grant. Unlike AssignRole it needs no client, so it can be
+// checked before a message from an unknown group is let through.
+func HasOwnerRole(jid waTypes.JID) bool {
+	if IsOwner(jid) {
+		return true
+	}
+
+	role, granted, err := storage.GetRole(jid.String())
+	if err != nil {
+		fmt.Println("Failed to get role grant for", jid.String(), ":", err)
+		return false
+	}
+	return granted && role == "OWNER"
+}
+
+func AssignRole(client *whatsmeow.Client, isFromGroup bool, chatJID, senderJID waTypes.JID) string {
+	if IsOwner(senderJID) {
 		return "OWNER"
 	}
 
+	role, granted, err := storage.GetRole(senderJID.String())
+	if err != nil {
+		fmt.Println("Failed to get role grant for", senderJID.String(), ":", err)
+	} else if granted {
+		return role
+	}
+
 	adminGroups := strings.Split(os.Getenv("ADMIN_GROUPS_JID"), ",")
 	userGroups := strings.Split(os.Getenv("USER_GROUPS_JID"), ",")
 
-	if isFromGroup {
-		if Contains(adminGroups, senderJID.String()) {
-			return "ADMIN"
-		} else if Contains(adminGroups, senderJID.String()) {
-			return "USER"
-		}
+	if isFromGroup && Contains(adminGroups, chatJID.String()) {
+		return "ADMIN"
 	}
 
-	isAdmin := false
 	for _, adminGroup := range adminGroups {
 		targetGroupJID, err := waTypes.ParseJID(adminGroup)
 		if err != nil {
@@ -34,25 +68,11 @@ func AssignRole(client *whatsmeow.Client, isFromGroup bool, senderJID waTypes.JI
 			continue
 		}
 
-		groupInfo, err := client.GetGroupInfo(targetGroupJID)
-		if err != nil {
-			fmt.Println("Failed to get group info for", adminGroup, ":", err)
-			continue
-		}
-
-		for _, participant := range groupInfo.Participants {
-			if participant.JID.String() == senderJID.String() {
-				isAdmin = true
-				break
-			}
-		}
-
-		if isAdmin {
+		if GroupCache.IsMember(client, targetGroupJID, senderJID) {
 			return "ADMIN"
 		}
 	}
 
-	isUser := false
 	for _, userGroup := range userGroups {
 		targetGroupJID, err := waTypes.ParseJID(userGroup)
 		if err != nil {
@@ -60,30 +80,60 @@ func AssignRole(client *whatsmeow.Client, isFromGroup bool, senderJID waTypes.JI
 			continue
 		}
 
-		groupInfo, err := client.GetGroupInfo(targetGroupJID)
-		if err != nil {
-			fmt.Println("Failed to get group info for", userGroup, ":", err)
-			continue
+		if GroupCache.IsMember(client, targetGroupJID, senderJID) {
+			return "USER"
 		}
+	}
 
-		for _, participant := range groupInfo.Participants {
-			if participant.JID.String() == senderJID.String() {
-				isUser = true
-				break
-			}
-		}
+	return "COMMON"
+}
 
-		if isUser {
-			return "USER"
+var ErrorInvalidNumber = errors.New("invalid phone number")
+
+// ParseUserJID turns a phone number ("+62 812-...", "@62812...") or a full JID
+// into a user JID.
+func ParseUserJID(input string) (waTypes.JID, error) {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "@")
+	if strings.Contains(input, "@") {
+		jid, err := waTypes.ParseJID(input)
+		if err != nil {
+			return waTypes.EmptyJID, ErrorInvalidNumber
 		}
+		return jid.ToNonAD(), nil
 	}
 
-	return "COMMON"
+	number := regexp.MustCompile(`\D`).ReplaceAllString(input, "")
+	if len(number) < 8 || len(number) > 15 {
+		return waTypes.EmptyJID, ErrorInvalidNumber
+	}
+
+	return waTypes.NewJID(number, waTypes.DefaultUserServer), nil
 }
 
 func IsFromAllowedGroups(vInfo *waTypes.MessageInfo) bool {
+	return IsAllowedGroup(vInfo.Chat)
+}
+
+// IsAllowedGroup reports whether the bot answers in a group, either because
+// it is an admin group from .env or because the owner allowed it.
+func IsAllowedGroup(group waTypes.JID) bool {
 	adminGroups := strings.Split(os.Getenv("ADMIN_GROUPS_JID"), ",")
-	groupJID := vInfo.Chat.String()
+	groupJID := group.String()
+
+	if Contains(adminGroups, groupJID) {
+		return true
+	}
+
+	settings, err := storage.GetGroupSettings(groupJID)
+	if err != nil {
+		fmt.Println("Failed to get group settings for", groupJID, ":", err)
+		return false
+	}
+
+	return settings.Allowed
+}
 
-	return Contains(adminGroups, groupJID)
+func IsGroupAdmin(client *whatsmeow.Client, groupJID, userJID waTypes.JID) bool {
+	participant, found := GroupCache.GetParticipant(client, groupJID, userJID)
+	return found && (participant.IsAdmin || participant.IsSuperAdmin)
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wa-bot/storage"
+
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+func TestParseUserJID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"6281234567890", "6281234567890@s.whatsapp.net"},
+		{"+62 812-3456-7890", "6281234567890@s.whatsapp.net"},
+		{"@6281234567890", "6281234567890@s.whatsapp.net"},
+		{"6281234567890@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"6281234567890:12@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"100000000000001@lid", "100000000000001@lid"},
+		{"12345", ""},
+		{"1234567890123456", ""},
+		{"not a number", ""},
+	}
+
+	for _, tt := range tests {
+		jid, err := ParseUserJID(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseUserJID(%q) = %v, want an error", tt.in, jid)
+			}
+			continue
+		}
+		if err != nil || jid.String() != tt.want {
+			t.Errorf("ParseUserJID(%q) = %v, %v, want %s", tt.in, jid, err, tt.want)
+		}
+	}
+}
+
+func TestHasOwnerRole(t *testing.T) {
+	if err := storage.Open("file:" + filepath.Join(t.TempDir(), "bot.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.DB.Close() })
+
+	envOwner := waTypes.NewJID("6281200000001", waTypes.DefaultUserServer)
+	dbOwner := waTypes.NewJID("6281200000002", waTypes.DefaultUserServer)
+	expiredOwner := waTypes.NewJID("6281200000003", waTypes.DefaultUserServer)
+	admin := waTypes.NewJID("6281200000004", waTypes.DefaultUserServer)
+	stranger := waTypes.NewJID("6281200000005", waTypes.DefaultUserServer)
+
+	t.Setenv("OWNER_JID", envOwner.String())
+	grants := []struct {
+		jid     waTypes.JID
+		role    string
+		expires time.Time
+	}{
+		{dbOwner, "OWNER", time.Time{}},
+		{expiredOwner, "OWNER", time.Now().Add(-time.Minute)},
+		{admin, "ADMIN", time.Time{}},
+	}
+	for _, grant := range grants {
+		if err := storage.GrantRole(grant.jid.String(), grant.role, envOwner.String(), grant.expires); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		jid  waTypes.JID
+		want bool
+	}{
+		{envOwner, true},
+		{dbOwner, true},
+		{expiredOwner, false},
+		{admin, false},
+		{stranger, false},
+	}
+	for _, tt := range tests {
+		if got := HasOwnerRole(tt.jid); got != tt.want {
+			t.Errorf("HasOwnerRole(%v) = %v, want %v", tt.jid, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// stickerCacheVersion is part of every cache key. Bump it when a change to
+// the conversion makes previously cached stickers wrong.
+const stickerCacheVersion = 1
+
+// HashFile returns the SHA-256 of a file, the same hash WhatsApp sends as
+// FileSHA256 for media.
+func HashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// StickerCacheKey identifies the conversion of a source file with the given
+// options. Options are normalized first so that equivalent requests share a
+// key, and the sticker metadata is left out since it is written per sender
+// after conversion.
+func StickerCacheKey(sourceHash []byte, opt *StickerOptions) (string, error) {
+	normalized := *opt
+	normalized.Metadata = StickerMetadata{}
+	startTime, endTime := ParseTimeFromString(opt.StartTime), ParseTimeFromString(opt.EndTime)
+	normalized.StartTime, normalized.EndTime = "", ""
+	normalized.Direction = strings.ToLower(normalized.Direction)
+	if normalized.Speed == 1 {
+		normalized.Speed = 0
+	}
+
+	if normalized.Overlay != "" {
+		overlayHash, err := HashFile(normalized.Overlay)
+		if err != nil {
+			return "", err
+		}
+		normalized.Overlay = hex.EncodeToString(overlayHash)
+	}
+
+	options, err := json.Marshal(struct {
+		Version   int
+		StartTime float64
+		EndTime   float64
+		Options   StickerOptions
+	}{
+		Version:   stickerCacheVersion,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Options:   normalized,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(sourceHash)
+	h.Write(options)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
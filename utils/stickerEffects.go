@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidColor = errors.New("invalid color")
+
+var namedColors = map[string]color.NRGBA{
+	"white":   {0xFF, 0xFF, 0xFF, 0xFF},
+	"black":   {0x00, 0x00, 0x00, 0xFF},
+	"red":     {0xFF, 0x00, 0x00, 0xFF},
+	"green":   {0x00, 0xFF, 0x00, 0xFF},
+	"blue":    {0x00, 0x00, 0xFF, 0xFF},
+	"yellow":  {0xFF, 0xFF, 0x00, 0xFF},
+	"cyan":    {0x00, 0xFF, 0xFF, 0xFF},
+	"magenta": {0xFF, 0x00, 0xFF, 0xFF},
+	"orange":  {0xFF, 0xA5, 0x00, 0xFF},
+	"purple":  {0x80, 0x00, 0x80, 0xFF},
+	"pink":    {0xFF, 0xC0, 0xCB, 0xFF},
+	"gray":    {0x80, 0x80, 0x80, 0xFF},
+	"grey":    {0x80, 0x80, 0x80, 0xFF},
+}
+
+// ParseColor accepts a color name such as "white" or a hex value written as
+// RRGGBB, #RRGGBB or 0xRRGGBB.
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "#"), "0x")
+	if len(hex) != 6 {
+		return color.NRGBA{}, ErrorInvalidColor
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, ErrorInvalidColor
+	}
+	return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}, nil
+}
+
+// ffmpegColor formats c for ffmpeg color options.
+func ffmpegColor(c color.NRGBA) string {
+	return fmt.Sprintf("0x%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// maxBufferedFrames bounds the clip of a reversed or boomerang sticker: the
+// reverse filter holds every decoded 512x512 frame, about 1MB each, in memory
+// until the input ends.
+const maxBufferedFrames = 150
+
+// timeRange returns the source seconds an animated sticker is made of.
+// start=, end= and MaxDuration always refer to the source, whatever the
+// speed. A reversed or boomerang clip is cut to maxBufferedFrames.
+func timeRange(opt *StickerOptions) (float64, float64) {
+	start := ParseTimeFromString(opt.StartTime)
+	end := start + float64(opt.MaxDuration)
+	if opt.EndTime != "" {
+		end = ParseTimeFromString(opt.EndTime)
+	}
+	if opt.Reverse || opt.Boomerang {
+		end = min(end, start+float64(maxBufferedFrames)*playbackSpeed(opt)/float64(opt.FPS))
+	}
+	return start, end
+}
+
+func playbackSpeed(opt *StickerOptions) float64 {
+	if opt.Speed == 0 {
+		return 1
+	}
+	return opt.Speed
+}
+
+// timeArgs returns the input seek, which goes before -i so ffmpeg does not
+// decode everything before start, and the output duration, which goes after
+// it and is counted after the speed change.
+func timeArgs(opt *StickerOptions) ([]string, []string) {
+	start, end := timeRange(opt)
+
+	var input []string
+	if start > 0 {
+		input = []string{"-ss", fmt.Sprintf("%g", start)}
+	}
+
+	// reverse only outputs once its input ends, and a boomerang plays for
+	// twice the clip, so those are cut by timeFilters instead.
+	if opt.Reverse || opt.Boomerang {
+		return input, nil
+	}
+	return input, []string{"-t", fmt.Sprintf("%g", (end-start)/playbackSpeed(opt))}
+}
+
+// timeFilters retimes an animated source before it is resampled to the
+// sticker frame rate.
+func timeFilters(opt *StickerOptions) []string {
+	var filters []string
+	if opt.Reverse || opt.Boomerang {
+		start, end := timeRange(opt)
+		filters = append(filters, fmt.Sprintf("trim=duration=%g", end-start), "setpts=PTS-STARTPTS")
+	}
+	if speed := playbackSpeed(opt); speed != 1 {
+		filters = append(filters, fmt.Sprintf("setpts=PTS/%g", speed))
+	}
+	return filters
+}
+
+// backgroundFilter keys out the background color, softening the edge a
+// little so JPEG noise around it does not leave a speckled halo.
+func backgroundFilter(c color.NRGBA, similarity float64) string {
+	if similarity == 0 {
+		similarity = 0.15
+	}
+	return fmt.Sprintf("format=rgba,colorkey=color=%s:similarity=%g:blend=%.3g", ffmpegColor(c), similarity, similarity/3)
+}
+
+// DetectBackgroundColor guesses the background of an image or video from the
+// corners of its first frame (or the frame at start, if given): the color
+// shared by most corners wins. It reports false when the corners are already
+// transparent or all differ.
+func DetectBackgroundColor(ctx context.Context, mediaPath string, start string) (color.NRGBA, bool, error) {
+	frame, err := firstFrame(ctx, mediaPath, start)
+	if err != nil {
+		return color.NRGBA{}, false, err
+	}
+	background, found := cornerColor(frame)
+	return background, found, nil
+}
+
+func cornerColor(frame image.Image) (color.NRGBA, bool) {
+	const patch = 5
+	b := frame.Bounds()
+	corners := []image.Point{
+		b.Min,
+		image.Pt(b.Max.X-patch, b.Min.Y),
+		image.Pt(b.Min.X, b.Max.Y-patch),
+		image.Pt(b.Max.X-patch, b.Max.Y-patch),
+	}
+
+	var samples []color.NRGBA
+	for _, corner := range corners {
+		sample := averageColor(frame, image.Rectangle{Min: corner, Max: corner.Add(image.Pt(patch, patch))}.Intersect(b))
+		if sample.A >= 128 {
+			samples = append(samples, sample)
+		}
+	}
+	if len(samples) < 2 {
+		return color.NRGBA{}, false
+	}
+
+	best, bestVotes := samples[0], 0
+	for _, candidate := range samples {
+		votes := 0
+		for _, other := range samples {
+			if colorDistance(candidate, other) <= 48 {
+				votes++
+			}
+		}
+		if votes > bestVotes {
+			best, bestVotes = candidate, votes
+		}
+	}
+	return best, bestVotes >= 2
+}
+
+// firstFrame decodes one frame of the media. WebP is decoded in Go since
+// ffmpeg cannot read animated WebP; everything else goes through the
+// Converter.
+func firstFrame(ctx context.Context, mediaPath string, start string) (image.Image, error) {
+	file, err := os.Open(mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 12)
+	_, err = io.ReadFull(file, header)
+	file.Close()
+
+	if err == nil && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP" {
+		data, err := os.ReadFile(mediaPath)
+		if err != nil {
+			return nil, err
+		}
+		return DecodeWebpFirstFrame(data)
+	}
+
+	return Converter.FirstFrame(ctx, mediaPath, start)
+}
+
+// FirstFrame extracts the frame at start, or the first one, with ffmpeg.
+func (FFmpegConverter) FirstFrame(ctx context.Context, mediaPath string, start string) (image.Image, error) {
+	var args []string
+	if start != "" {
+		args = append(args, "-ss", fmt.Sprintf("%g", ParseTimeFromString(start)))
+	}
+	args = append(args, "-v", "error", "-i", mediaPath, "-frames:v", "1", "-f", "image2pipe", "-c:v", "png", "-")
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(output))
+}
+
+func averageColor(img image.Image, rect image.Rectangle) color.NRGBA {
+	var r, g, b, a, n uint32
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r, g, b, a, n = r+uint32(c.R), g+uint32(c.G), b+uint32(c.B), a+uint32(c.A), n+1
+		}
+	}
+	if n == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)}
+}
+
+func colorDistance(a, b color.NRGBA) int {
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	return abs(int(a.R)-int(b.R)) + abs(int(a.G)-int(b.G)) + abs(int(a.B)-int(b.B))
+}
+
+// effectFilters applies the flip, rotation, color and shape options to the
+// 512x512 frames.
+func effectFilters(opt *StickerOptions) []string {
+	var filters []string
+	if opt.Mirror {
+		filters = append(filters, "hflip")
+	}
+	if opt.Flip {
+		filters = append(filters, "vflip")
+	}
+	switch opt.Rotate {
+	case 90:
+		filters = append(filters, "transpose=clock")
+	case 180:
+		filters = append(filters, "hflip", "vflip")
+	case 270:
+		filters = append(filters, "transpose=cclock")
+	}
+	if opt.Grayscale {
+		filters = append(filters, "hue=s=0")
+	}
+	if shape := shapeFilter(opt.Shape, opt.Border); shape != "" {
+		filters = append(filters, "format=rgba", shape)
+	}
+	return filters
+}
+
+// shapeFilter cuts the frame to a circle or rounded square and draws the
+// optional border along the edge of the shape. Both are computed per pixel
+// from the signed distance to the shape's edge (negative inside), which also
+// gives a one pixel anti-aliased edge.
+func shapeFilter(shape string, border *color.NRGBA) string {
+	var radius string
+	switch shape {
+	case "circle":
+		radius = "W/2"
+	case "rounded":
+		radius = "W/8"
+	case "square", "":
+		if border == nil {
+			return ""
+		}
+		radius = "0"
+	default:
+		return ""
+	}
+
+	qx := fmt.Sprintf("(abs(X+0.5-W/2)-(W/2-%s))", radius)
+	qy := fmt.Sprintf("(abs(Y+0.5-H/2)-(H/2-%s))", radius)
+	// Each channel stores the distance in variable 0 before using it.
+	dist := fmt.Sprintf("st(0,hypot(max(%s,0),max(%s,0))+min(max(%s,%s),0)-%s);", qx, qy, qx, qy, radius)
+	coverage := "clip(0.5-ld(0),0,1)"
+
+	if border == nil {
+		return fmt.Sprintf("geq=r='r(X,Y)':g='g(X,Y)':b='b(X,Y)':a='%salpha(X,Y)*%s'", dist, coverage)
+	}
+
+	const thickness = 12
+	inBorder := fmt.Sprintf("clip(ld(0)+%d.5,0,1)", thickness)
+	channel := func(current string, value uint8) string {
+		return fmt.Sprintf("%s%s*(1-%s)+%d*%s", dist, current, inBorder, value, inBorder)
+	}
+	return fmt.Sprintf("geq=r='%s':g='%s':b='%s':a='(%s)*%s'",
+		channel("r(X,Y)", border.R),
+		channel("g(X,Y)", border.G),
+		channel("b(X,Y)", border.B),
+		channel("alpha(X,Y)", 255),
+		coverage,
+	)
+}
+
+// boomerangFilter plays the clip forwards and then backwards.
+const boomerangFilter = "split[forward][backward];[backward]reverse[reversed];[forward][reversed]concat=n=2:v=1:a=0"
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "media")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCornerColor(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	green := color.NRGBA{0, 255, 0, 255}
+
+	fill := func(corners ...color.NRGBA) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		for i, c := range corners {
+			x, y := (i%2)*30, (i/2)*30
+			draw.Draw(img, image.Rect(x, y, x+10, y+10), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+		return img
+	}
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		want  color.NRGBA
+		found bool
+	}{
+		{"uniform", fill(red, red, red, red), red, true},
+		{"majority", fill(red, blue, red, red), red, true},
+		{"near colors vote together", fill(red, color.NRGBA{240, 10, 10, 255}, blue, green), red, true},
+		{"all different", fill(red, blue, green, color.NRGBA{255, 255, 0, 255}), color.NRGBA{}, false},
+		{"transparent", fill(color.NRGBA{}, color.NRGBA{}, color.NRGBA{}, red), color.NRGBA{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := cornerColor(tt.img)
+			if found != tt.found || (found && got != tt.want) {
+				t.Fatalf("got %v %v, want %v %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestDetectBackgroundColorWebp(t *testing.T) {
+	ctx := context.Background()
+	lossy, lossyFound, err := DetectBackgroundColor(ctx, writeTemp(t, readSample(t, "lossy.webp")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first frame of an animation is sampled, which is the lossy
+	// sample here.
+	animated, animatedFound, err := DetectBackgroundColor(ctx, writeTemp(t, sampleAnimatedWebp(t)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if animated != lossy || animatedFound != lossyFound {
+		t.Fatalf("animated: got %v %v, want %v %v", animated, animatedFound, lossy, lossyFound)
+	}
+}
+
+func TestDetectBackgroundColorRejectsCraftedWebp(t *testing.T) {
+	crafted := encodeWebpChunks([]webpChunk{{FourCC: "VP8X", Payload: []byte{vp8xFlagAnimation}}})
+	_, _, err := DetectBackgroundColor(context.Background(), writeTemp(t, crafted), "")
+	if !errors.Is(err, ErrorInvalidWebp) {
+		t.Fatalf("got %v, want %v", err, ErrorInvalidWebp)
+	}
+}
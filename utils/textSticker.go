@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Fonts are embedded through x/image/font/gofont so text rendering works in
+// the Alpine image without any font packages installed.
+
+var ErrorNoRenderableText = errors.New("text has no renderable characters")
+
+var (
+	boldFontOnce sync.Once
+	boldFont     *opentype.Font
+	boldFontErr  error
+)
+
+func loadBoldFont() (*opentype.Font, error) {
+	boldFontOnce.Do(func() {
+		boldFont, boldFontErr = opentype.Parse(gobold.TTF)
+	})
+	return boldFont, boldFontErr
+}
+
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// renderableText drops characters the font has no glyph for, such as emoji,
+// instead of drawing them as boxes.
+func renderableText(f *opentype.Font, text string) string {
+	var buf sfnt.Buffer
+	var b strings.Builder
+	for _, r := range text {
+		if r == '\n' || unicode.IsSpace(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if idx, err := f.GlyphIndex(&buf, r); err == nil && idx != 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// wrapText breaks text into lines no wider than maxWidth. Explicit newlines
+// are kept and words longer than a line are split by character.
+func wrapText(face font.Face, text string, maxWidth int) []string {
+	limit := fixed.I(maxWidth)
+	var lines []string
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := strings.TrimSpace(line + " " + word)
+			if font.MeasureString(face, candidate) <= limit {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && font.MeasureString(face, line+string(r)) > limit {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// fitText picks the largest font size, up to maxSize, at which the wrapped
+// text fits in width x height, and returns the face and the wrapped lines.
+func fitText(f *opentype.Font, text string, width, height int, maxSize, minSize float64) (font.Face, []string, error) {
+	for size := maxSize; ; size *= 0.9 {
+		if size < minSize {
+			size = minSize
+		}
+
+		face, err := newFace(f, size)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lines := wrapText(face, text, width)
+		lineHeight := face.Metrics().Height.Ceil()
+		if lineHeight*len(lines) <= height || size == minSize {
+			return face, lines, nil
+		}
+		face.Close()
+	}
+}
+
+// drawTextMask renders lines centered in bounds into an alpha mask.
+func drawTextMask(bounds image.Rectangle, face font.Face, lines []string) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	drawTextLines(mask, bounds, face, lines)
+	return mask
+}
+
+// drawTextLines draws lines horizontally centered in area, starting at its
+// top. Callers size area to the text block to place it.
+func drawTextLines(mask *image.Alpha, area image.Rectangle, face font.Face, lines []string) {
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Ceil()
+	top := area.Min.Y + (area.Dy()-lineHeight*len(lines))/2
+
+	drawer := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face}
+	for i, line := range lines {
+		width := drawer.MeasureString(line).Ceil()
+		x := area.Min.X + (area.Dx()-width)/2
+		y := top + i*lineHeight + metrics.Ascent.Ceil()
+		drawer.Dot = fixed.P(x, y)
+		drawer.DrawString(line)
+	}
+}
+
+// outlineMask grows mask by radius pixels so it can be drawn behind the text
+// as an outline.
+func outlineMask(mask *image.Alpha, radius int) *image.Alpha {
+	outline := image.NewAlpha(mask.Bounds())
+	if radius <= 0 {
+		return outline
+	}
+
+	steps := 24
+	for _, r := range []int{radius, (radius + 1) / 2} {
+		for i := 0; i < steps; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(steps)
+			offset := image.Pt(int(math.Round(float64(r)*math.Cos(angle))), int(math.Round(float64(r)*math.Sin(angle))))
+			draw.DrawMask(outline, outline.Bounds().Add(offset), image.Opaque, image.Point{}, mask, mask.Bounds().Min, draw.Over)
+		}
+	}
+	draw.DrawMask(outline, outline.Bounds(), image.Opaque, image.Point{}, mask, mask.Bounds().Min, draw.Over)
+	return outline
+}
+
+// textLayers renders text for a 512x512 sticker and returns the fill mask and
+// its outline mask.
+func textLayers(text string) (*image.Alpha, *image.Alpha, error) {
+	f, err := loadBoldFont()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	text = strings.TrimSpace(renderableText(f, text))
+	if text == "" {
+		return nil, nil, ErrorNoRenderableText
+	}
+
+	const margin = 24
+	canvas := image.Rect(0, 0, 512, 512)
+	face, lines, err := fitText(f, text, 512-2*margin, 512-2*margin, 160, 20)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer face.Close()
+
+	mask := drawTextMask(canvas, face, lines)
+	radius := max(2, face.Metrics().Height.Round()/18)
+	return mask, outlineMask(mask, radius), nil
+}
+
+func composeText(fill, outline *image.Alpha, fillColor color.Color) *image.RGBA {
+	img := image.NewRGBA(fill.Bounds())
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, outline, outline.Bounds().Min, draw.Over)
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(fillColor), image.Point{}, fill, fill.Bounds().Min, draw.Over)
+	return img
+}
+
+// RenderTextPng renders text as a 512x512 transparent PNG for !ttp.
+func RenderTextPng(text string) (string, error) {
+	pngPath := filepath.Join("media", fmt.Sprintf("ttp_%d.png", time.Now().UnixNano()))
+
+	fill, outline, err := textLayers(text)
+	if err != nil {
+		return pngPath, err
+	}
+
+	return pngPath, writePng(pngPath, composeText(fill, outline, color.White))
+}
+
+// RenderCaptionPng renders meme captions as a transparent 512x512 PNG to be
+// overlaid on a sticker: white, outlined, upper case text at the top and/or
+// bottom, wrapped and shrunk to fit a third of the height each.
+func RenderCaptionPng(top, bottom string) (string, error) {
+	pngPath := filepath.Join("media", fmt.Sprintf("caption_%d.png", time.Now().UnixNano()))
+
+	f, err := loadBoldFont()
+	if err != nil {
+		return pngPath, err
+	}
+
+	top = strings.ToUpper(strings.TrimSpace(renderableText(f, top)))
+	bottom = strings.ToUpper(strings.TrimSpace(renderableText(f, bottom)))
+	if top == "" && bottom == "" {
+		return pngPath, ErrorNoRenderableText
+	}
+
+	const margin = 12
+	const regionHeight = 512 / 3
+	fill := image.NewAlpha(image.Rect(0, 0, 512, 512))
+	radius := 2
+
+	for i, caption := range []string{top, bottom} {
+		if caption == "" {
+			continue
+		}
+
+		face, lines, err := fitText(f, caption, 512-2*margin, regionHeight, 72, 18)
+		if err != nil {
+			return pngPath, err
+		}
+
+		height := face.Metrics().Height.Ceil() * len(lines)
+		area := image.Rect(margin, margin, 512-margin, margin+height)
+		if i == 1 {
+			area = image.Rect(margin, 512-margin-height, 512-margin, 512-margin)
+		}
+		drawTextLines(fill, area, face, lines)
+		radius = max(radius, face.Metrics().Height.Round()/16)
+		face.Close()
+	}
+
+	return pngPath, writePng(pngPath, composeText(fill, outlineMask(fill, radius), color.White))
+}
+
+// RenderTextGif renders text as a 512x512 GIF whose fill color cycles
+// through the hue wheel, for !attp.
+func RenderTextGif(text string) (string, error) {
+	gifPath := filepath.Join("media", fmt.Sprintf("attp_%d.gif", time.Now().UnixNano()))
+
+	fill, outline, err := textLayers(text)
+	if err != nil {
+		return gifPath, err
+	}
+
+	const frames = 12
+	anim := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := composeText(fill, outline, hueColor(float64(i)/frames))
+		anim.Image = append(anim.Image, toTransparentPaletted(frame))
+		anim.Delay = append(anim.Delay, 10)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+	}
+
+	file, err := os.Create(gifPath)
+	if err != nil {
+		return gifPath, err
+	}
+	defer file.Close()
+
+	return gifPath, gif.EncodeAll(file, anim)
+}
+
+// toTransparentPaletted maps img onto the web-safe palette, with index 0
+// reserved for fully transparent pixels since GIF has no partial alpha.
+func toTransparentPaletted(img *image.RGBA) *image.Paletted {
+	pal := append(color.Palette{color.Transparent}, palette.WebSafe...)
+	out := image.NewPaletted(img.Bounds(), pal)
+	opaque := pal[1:]
+
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if c.A < 128 {
+				continue
+			}
+			unpremultiplied := color.NRGBAModel.Convert(c).(color.NRGBA)
+			unpremultiplied.A = 255
+			out.SetColorIndex(x, y, uint8(opaque.Index(unpremultiplied)+1))
+		}
+	}
+	return out
+}
+
+// hueColor returns a fully saturated color at position h (0-1) on the hue
+// wheel.
+func hueColor(h float64) color.Color {
+	h = math.Mod(h, 1) * 6
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = 1, x, 0
+	case 1:
+		r, g, b = x, 1, 0
+	case 2:
+		r, g, b = 0, 1, x
+	case 3:
+		r, g, b = 0, x, 1
+	case 4:
+		r, g, b = x, 0, 1
+	default:
+		r, g, b = 1, 0, x
+	}
+	return color.NRGBA{uint8(r * 255), uint8(g * 255), uint8(b * 255), 255}
+}
@@ -1,17 +1,13 @@
 package utils
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -41,9 +37,31 @@ func GetLinkFromString(input string) (string, error) {
 	return "", fmt.Errorf("no link found / invalid link")
 }
 
+// GetLinksFromString returns every link in input, in order and without
+// duplicates.
+func GetLinksFromString(input string) []string {
+	urlRegex := regexp.MustCompile(`^(https?:\/\/)?([\w-]+\.)+[\w-]+(:\d+)?(\/[\w\-\.~!*'();:@&=+$,/?%#]*)?$`)
+	var links []string
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(input) {
+		if urlRegex.MatchString(word) && !seen[word] {
+			seen[word] = true
+			links = append(links, word)
+		}
+	}
+	return links
+}
+
 var ErrorNotSupportedLink = errors.New("link not supported")
 
+// DownloadMediaFromURL downloads an image or video link to the media folder
+// with the current Converter and returns its path and MIME type.
 func DownloadMediaFromURL(ctx context.Context, url string) (string, string, error) {
+	return Converter.DownloadMedia(ctx, url)
+}
+
+// DownloadMedia tries yt-dlp, then gallery-dl, then a plain HTTP GET.
+func (FFmpegConverter) DownloadMedia(ctx context.Context, url string) (string, string, error) {
 	currentTime := fmt.Sprintf("%d", time.Now().UnixMilli())
 	mediaPath := "media/" + currentTime
 
@@ -135,46 +153,19 @@ func GetMimeType(filePath string) (string, error) {
 	return mime.String(), nil
 }
 
-func WriteWebpExifFile(ctx context.Context, inputPath string, packName, author string) (string, error) {
-	timestamp := time.Now().Unix()
-	filenameBase := fmt.Sprintf("%d_convert", timestamp)
-
-	outputPath := filepath.Join("media", filenameBase+"_output.webp")
-	exifPath := filepath.Join("media", filenameBase+"_meta.exif")
-	defer os.Remove(exifPath)
-
-	var b bytes.Buffer
-	startingBytes := []byte{0x49, 0x49, 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x41, 0x57, 0x07, 0x00}
-	endingBytes := []byte{0x16, 0x00, 0x00, 0x00}
-
-	meta := map[string]any{
-		"sticker-pack-id":        "site.alyza.custompack",
-		"sticker-pack-name":      packName,
-		"sticker-pack-publisher": author,
-	}
-	jsonBytes, err := json.Marshal(meta)
-	if err != nil {
-		return "", err
-	}
-
-	lenBuf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(lenBuf, uint32(len(jsonBytes)))
-
-	b.Write(startingBytes)
-	b.Write(lenBuf)
-	b.Write(endingBytes)
-	b.Write(jsonBytes)
-
-	if err := os.WriteFile(exifPath, b.Bytes(), 0644); err != nil {
-		return "", err
-	}
-
-	cmd := exec.CommandContext(ctx, "webpmux", "-set", "exif", exifPath, inputPath, "-o", outputPath)
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
+// StickerMetadata is the JSON WhatsApp reads from a sticker's EXIF chunk.
+// Stickers sharing a PackID are grouped together in the sticker tray.
+type StickerMetadata struct {
+	PackID    string
+	PackName  string
+	Publisher string
+	Emojis    []string
+}
 
-	return outputPath, nil
+// WriteWebpExifFile writes a copy of the WebP at inputPath with the sticker
+// metadata set as its EXIF chunk and returns the copy's path.
+func WriteWebpExifFile(ctx context.Context, inputPath string, metadata StickerMetadata) (string, error) {
+	return Converter.WriteWebpExif(ctx, inputPath, metadata)
 }
 
 func IsCanceledGoroutine(ctx context.Context) bool {
@@ -204,24 +195,45 @@ func IsValidTimeFormat (t string) bool {
 	return sec <= 59
 }
 
-var ErrorNotVideo = errors.New("not video")
+var ErrorInvalidDuration = errors.New("invalid duration")
 
-func GetMediaDuration(filePath string) (float64, error) {
-	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1", filePath)
+// MaxParsedDuration is the longest duration ParseDuration accepts, well below
+// where time.Duration overflows.
+const MaxParsedDuration = 100 * 365 * 24 * time.Hour
 
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
+// ParseDuration parses durations such as 30m, 12h, 7d or 2w, up to
+// MaxParsedDuration.
+func ParseDuration(s string) (time.Duration, error) {
+	re := regexp.MustCompile(`^(\d+)([mhdw])$`)
+	matches := re.FindStringSubmatch(strings.ToLower(s))
+	if matches == nil {
+		return 0, ErrorInvalidDuration
 	}
 
-	durationStr := strings.TrimSpace(string(output))
-	duration, err := strconv.ParseFloat(durationStr, 64)
-	if err != nil {
-		return 0, ErrorNotVideo
+	n, err := strconv.Atoi(matches[1])
+	if err != nil || n <= 0 {
+		return 0, ErrorInvalidDuration
+	}
+
+	units := map[string]time.Duration{
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
 	}
+	unit := units[matches[2]]
+	if int64(n) > int64(MaxParsedDuration/unit) {
+		return 0, ErrorInvalidDuration
+	}
+	return time.Duration(n) * unit, nil
+}
+
+var ErrorNotVideo = errors.New("not video")
 
-	return duration, nil
+// GetMediaDuration returns the duration of a media file in seconds, or
+// ErrorNotVideo when it has none.
+func GetMediaDuration(filePath string) (float64, error) {
+	return Converter.MediaDuration(filePath)
 }
 
 func ParseTimeFromString (t string) float64 {
@@ -237,21 +249,56 @@ func ParseTimeFromString (t string) float64 {
 var ErrorPageNumberExceeded = errors.New("given page exceeded")
 var ErrorPageNumberNotGiven = errors.New("no instagram page number given")
 
-func GetInstagramDirectURL(url string, page int) (string, error) {
+var ErrorInvalidPageRange = errors.New("invalid page range")
+
+// PageRange selects carousel items by 1-based, inclusive page numbers. The
+// zero value selects nothing, and a Last of -1 runs to the last page.
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// ParsePageRange parses "3", "1-4" or "all".
+func ParsePageRange(s string) (PageRange, error) {
+	if strings.EqualFold(s, "all") {
+		return PageRange{First: 1, Last: -1}, nil
+	}
+
+	first, last, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 1 {
+		return PageRange{}, ErrorInvalidPageRange
+	}
+	if !isRange {
+		return PageRange{First: start, Last: start}, nil
+	}
+
+	end, err := strconv.Atoi(last)
+	if err != nil || end < start {
+		return PageRange{}, ErrorInvalidPageRange
+	}
+	return PageRange{First: start, Last: end}, nil
+}
+
+func GetInstagramDirectURLs(url string, pages PageRange) ([]string, error) {
 	urls, err := instagramdl.GetInstagramMediaURLs(url)
 	if err != nil || len(urls) == 0 {
-		return "", fmt.Errorf("failed to get direct url")
+		return nil, fmt.Errorf("failed to get direct url")
 	}
 
 	if len(urls) > 1 {
-		if page <= 0 {
-			return "", ErrorPageNumberNotGiven
+		if pages.First <= 0 {
+			return nil, ErrorPageNumberNotGiven
+		}
+		last := pages.Last
+		if last < 0 {
+			last = len(urls)
 		}
-		if page > len(urls) {
-			return "", ErrorPageNumberExceeded
+		if pages.First > len(urls) || last > len(urls) {
+			return nil, ErrorPageNumberExceeded
 		}
-		return urls[page-1], nil
+		return urls[pages.First-1 : last], nil
 	}
 
-	return urls[0], nil
+	return urls, nil
 }
\ No newline at end of file
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"30m", 30 * time.Minute, false},
+		{"12H", 12 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"5200w", 5200 * 7 * 24 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1d", 0, true},
+		{"1y", 0, true},
+		{"d", 0, true},
+		{"100000w", 0, true},
+		{"9223372036854775807m", 0, true},
+		{"99999999999999999999w", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrorInvalidDuration) {
+				t.Errorf("ParseDuration(%q) = %v, %v, want an error", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// A .wastickers file is a zip read by third-party sticker apps to install a
+// pack in WhatsApp: the WebP stickers, a 96x96 PNG tray icon, and the pack
+// title and author in title.txt and author.txt.
+
+const (
+	MinPackStickers = 3
+	MaxPackStickers = 30
+	trayIconSize    = 96
+)
+
+var ErrorPackSize = fmt.Errorf("a sticker pack needs %d to %d stickers", MinPackStickers, MaxPackStickers)
+
+// BuildWastickers zips stickers into a .wastickers pack. The tray icon is
+// made from the first frame of the first sticker.
+func BuildWastickers(title, author string, stickers [][]byte) ([]byte, error) {
+	if len(stickers) < MinPackStickers || len(stickers) > MaxPackStickers {
+		return nil, ErrorPackSize
+	}
+
+	tray, err := renderTrayIcon(stickers[0])
+	if err != nil {
+		return nil, fmt.Errorf("tray icon: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := map[string][]byte{
+		"title.txt":  []byte(title),
+		"author.txt": []byte(author),
+		"tray.png":   tray,
+	}
+	names := []string{"title.txt", "author.txt", "tray.png"}
+	for i, sticker := range stickers {
+		name := fmt.Sprintf("%02d.webp", i+1)
+		files[name] = sticker
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderTrayIcon(webpData []byte) ([]byte, error) {
+	frames, _, err := DecodeWebpFrames(webpData)
+	if err != nil {
+		return nil, err
+	}
+
+	icon := image.NewNRGBA(image.Rect(0, 0, trayIconSize, trayIconSize))
+	xdraw.CatmullRom.Scale(icon, icon.Bounds(), frames[0], frames[0].Bounds(), xdraw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, icon); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
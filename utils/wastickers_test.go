@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBuildWastickers(t *testing.T) {
+	lossy := readSample(t, "lossy.webp")
+	stickers := [][]byte{sampleAnimatedWebp(t), lossy, readSample(t, "lossless.webp")}
+
+	data, err := BuildWastickers("My Pack", "Me", stickers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"title.txt", "author.txt", "tray.png", "01.webp", "02.webp", "03.webp"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got %v, want %v", names, want)
+		}
+	}
+}
+
+func TestBuildWastickersRejects(t *testing.T) {
+	lossy := readSample(t, "lossy.webp")
+	crafted := encodeWebpChunks([]webpChunk{{FourCC: "VP8X", Payload: []byte{vp8xFlagAnimation}}})
+
+	tests := []struct {
+		name     string
+		stickers [][]byte
+		want     error
+	}{
+		{"too few", [][]byte{lossy, lossy}, ErrorPackSize},
+		{"crafted tray sticker", [][]byte{crafted, lossy, lossy}, ErrorInvalidWebp},
+		{"oversized tray sticker", [][]byte{animatedWebp(1<<24, 1<<24), lossy, lossy}, ErrorWebpTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildWastickers("pack", "me", tt.stickers)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
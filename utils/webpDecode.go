@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+const defaultFrameDuration = 100 * time.Millisecond
+
+// Stickers come from users, so the sizes in their headers are not trusted.
+// The canvas is capped, and so is the total of canvas pixels over all frames
+// since every frame is kept as a full-canvas image.
+const (
+	maxWebpCanvas      = 2048
+	maxWebpTotalPixels = 512 * 512 * 500
+)
+
+var ErrorWebpTooLarge = fmt.Errorf("%w: image too large", ErrorInvalidWebp)
+
+// DecodeWebpFrames decodes every frame of a WebP, static or animated, into
+// full-canvas images. ffmpeg and x/image/webp cannot decode animated WebP,
+// so ANMF frames are demuxed here, decoded one by one and composited.
+func DecodeWebpFrames(data []byte) ([]image.Image, []time.Duration, error) {
+	return decodeWebpFrames(data, 0)
+}
+
+// DecodeWebpFirstFrame decodes only the first frame of a WebP.
+func DecodeWebpFirstFrame(data []byte) (image.Image, error) {
+	frames, _, err := decodeWebpFrames(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	return frames[0], nil
+}
+
+// decodeWebpFrames stops after limit frames, or decodes them all when limit
+// is 0.
+func decodeWebpFrames(data []byte, limit int) ([]image.Image, []time.Duration, error) {
+	chunks, err := parseWebpChunks(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !IsAnimatedWebp(data) {
+		img, err := decodeWebpImage(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []image.Image{img}, []time.Duration{0}, nil
+	}
+
+	vp8x := chunks[0].Payload
+	if len(vp8x) < 10 {
+		return nil, nil, fmt.Errorf("%w: short VP8X chunk", ErrorInvalidWebp)
+	}
+	canvasWidth := int(uint24(vp8x[4:7])) + 1
+	canvasHeight := int(uint24(vp8x[7:10])) + 1
+	if canvasWidth > maxWebpCanvas || canvasHeight > maxWebpCanvas {
+		return nil, nil, ErrorWebpTooLarge
+	}
+	frameCount := 0
+	for _, chunk := range chunks {
+		if chunk.FourCC == "ANMF" {
+			frameCount++
+		}
+	}
+	if frameCount*canvasWidth*canvasHeight > maxWebpTotalPixels {
+		return nil, nil, ErrorWebpTooLarge
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, canvasWidth, canvasHeight))
+
+	var frames []image.Image
+	var durations []time.Duration
+	var dispose image.Rectangle
+
+	for _, chunk := range chunks {
+		if chunk.FourCC != "ANMF" {
+			continue
+		}
+		if limit > 0 && len(frames) == limit {
+			break
+		}
+		p := chunk.Payload
+		if len(p) < 16 {
+			return nil, nil, fmt.Errorf("%w: short ANMF chunk", ErrorInvalidWebp)
+		}
+
+		if !dispose.Empty() {
+			draw.Draw(canvas, dispose, image.Transparent, image.Point{}, draw.Src)
+			dispose = image.Rectangle{}
+		}
+
+		x := int(uint24(p[0:3])) * 2
+		y := int(uint24(p[3:6])) * 2
+		rect := image.Rect(x, y, x+int(uint24(p[6:9]))+1, y+int(uint24(p[9:12]))+1)
+		if !rect.In(canvas.Bounds()) {
+			return nil, nil, fmt.Errorf("%w: frame outside the canvas", ErrorInvalidWebp)
+		}
+		duration := time.Duration(uint24(p[12:15])) * time.Millisecond
+		noBlend := p[15]&0x02 != 0
+		disposeToBackground := p[15]&0x01 != 0
+
+		frame, err := decodeAnimationFrame(p[16:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if frame.Bounds().Dx() != rect.Dx() || frame.Bounds().Dy() != rect.Dy() {
+			return nil, nil, fmt.Errorf("%w: frame size does not match its header", ErrorInvalidWebp)
+		}
+
+		op := draw.Over
+		if noBlend {
+			op = draw.Src
+		}
+		draw.Draw(canvas, rect, frame, frame.Bounds().Min, op)
+
+		snapshot := image.NewRGBA(canvas.Bounds())
+		copy(snapshot.Pix, canvas.Pix)
+		frames = append(frames, snapshot)
+
+		if duration <= 0 {
+			duration = defaultFrameDuration
+		}
+		durations = append(durations, duration)
+
+		if disposeToBackground {
+			dispose = rect
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil, nil, fmt.Errorf("%w: animation has no frames", ErrorInvalidWebp)
+	}
+	return frames, durations, nil
+}
+
+// decodeAnimationFrame wraps the ALPH/VP8/VP8L sub-chunks of an ANMF frame
+// into a standalone WebP so x/image/webp can decode it.
+func decodeAnimationFrame(frameData []byte) (image.Image, error) {
+	wrapped := make([]byte, 12, 12+len(frameData))
+	copy(wrapped[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(wrapped[4:8], uint32(4+len(frameData)))
+	copy(wrapped[8:12], "WEBP")
+	wrapped = append(wrapped, frameData...)
+
+	return decodeWebpImage(wrapped)
+}
+
+// decodeWebpImage decodes a single image. The file is rebuilt from just its
+// bitstream chunks first, because x/image/webp rejects VP8X files that carry
+// VP8L data with the alpha flag set, which is what most encoders produce.
+func decodeWebpImage(data []byte) (image.Image, error) {
+	chunks, err := parseWebpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var alph, bitstream *webpChunk
+	for i, chunk := range chunks {
+		switch chunk.FourCC {
+		case "ALPH":
+			alph = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, fmt.Errorf("%w: no image bitstream", ErrorInvalidWebp)
+	}
+	width, height, _, err := webpCanvasSize(*bitstream)
+	if err != nil {
+		return nil, err
+	}
+	if width > maxWebpCanvas || height > maxWebpCanvas {
+		return nil, ErrorWebpTooLarge
+	}
+
+	simple := []webpChunk{*bitstream}
+	if alph != nil && bitstream.FourCC == "VP8 " {
+		simple = []webpChunk{newVP8X(width, height, vp8xFlagAlpha), *alph, *bitstream}
+	}
+
+	img, err := webp.Decode(bytes.NewReader(encodeWebpChunks(simple)))
+	if err != nil {
+		return nil, errors.Join(ErrorInvalidWebp, err)
+	}
+	return img, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// testdata/lossy.webp (VP8, 150x100) and testdata/lossless.webp (VP8L,
+// 75x100) are encoder outputs from the golang.org/x/image test suite. The
+// animated samples are assembled from their bitstreams.
+
+func readSample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// bitstreamChunks returns the chunks of a simple WebP, to be used as the
+// data of an ANMF frame.
+func bitstreamChunks(t *testing.T, data []byte) []byte {
+	t.Helper()
+	return data[12:]
+}
+
+type testFrame struct {
+	X, Y          int // even offsets on the canvas
+	Width, Height int
+	DurationMs    int
+	Data          []byte
+}
+
+func anmfChunk(frame testFrame) webpChunk {
+	payload := make([]byte, 16, 16+len(frame.Data))
+	putUint24(payload[0:3], uint32(frame.X/2))
+	putUint24(payload[3:6], uint32(frame.Y/2))
+	putUint24(payload[6:9], uint32(frame.Width-1))
+	putUint24(payload[9:12], uint32(frame.Height-1))
+	putUint24(payload[12:15], uint32(frame.DurationMs))
+	return webpChunk{FourCC: "ANMF", Payload: append(payload, frame.Data...)}
+}
+
+func animatedWebp(width, height int, frames ...testFrame) []byte {
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:6], 0)
+	chunks := []webpChunk{newVP8X(width, height, vp8xFlagAnimation|vp8xFlagAlpha), {FourCC: "ANIM", Payload: anim}}
+	for _, frame := range frames {
+		chunks = append(chunks, anmfChunk(frame))
+	}
+	return encodeWebpChunks(chunks)
+}
+
+func sampleAnimatedWebp(t *testing.T) []byte {
+	t.Helper()
+	lossy := bitstreamChunks(t, readSample(t, "lossy.webp"))
+	lossless := bitstreamChunks(t, readSample(t, "lossless.webp"))
+	return animatedWebp(150, 100,
+		testFrame{Width: 150, Height: 100, DurationMs: 80, Data: lossy},
+		testFrame{X: 74, Width: 75, Height: 100, DurationMs: 0, Data: lossless},
+	)
+}
+
+func TestDecodeWebpFrames(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		frames    int
+		width     int
+		durations []int
+	}{
+		{"lossy", readSample(t, "lossy.webp"), 1, 150, []int{0}},
+		{"lossless", readSample(t, "lossless.webp"), 1, 75, []int{0}},
+		{"animated", sampleAnimatedWebp(t), 2, 150, []int{80, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, durations, err := DecodeWebpFrames(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != tt.frames {
+				t.Fatalf("got %d frames, want %d", len(frames), tt.frames)
+			}
+			for i, frame := range frames {
+				if frame.Bounds().Dx() != tt.width || frame.Bounds().Dy() != 100 {
+					t.Errorf("frame %d is %v", i, frame.Bounds())
+				}
+				if got := int(durations[i].Milliseconds()); got != tt.durations[i] {
+					t.Errorf("frame %d lasts %dms, want %dms", i, got, tt.durations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWebpFramesRejectsBadHeaders(t *testing.T) {
+	lossless := bitstreamChunks(t, readSample(t, "lossless.webp"))
+	frame := testFrame{Width: 75, Height: 100, Data: lossless}
+
+	shortVP8X := encodeWebpChunks([]webpChunk{{FourCC: "VP8X", Payload: []byte{vp8xFlagAnimation}}, anmfChunk(frame)})
+
+	hugeStatic := readSample(t, "lossless.webp")
+	hugeStatic = append([]byte(nil), hugeStatic...)
+	// Rewrite the VP8L header to claim 16384x16384.
+	binary.LittleEndian.PutUint32(hugeStatic[21:25], 0x3fff|0x3fff<<14)
+
+	outside := frame
+	outside.X = 100
+
+	wrongSize := frame
+	wrongSize.Width, wrongSize.Height = 10, 10
+
+	var manyFrames []testFrame
+	for range maxWebpTotalPixels/(75*100) + 1 {
+		manyFrames = append(manyFrames, frame)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"truncated VP8X", shortVP8X, ErrorInvalidWebp},
+		{"oversized canvas", animatedWebp(1<<24, 1<<24, frame), ErrorWebpTooLarge},
+		{"oversized static image", hugeStatic, ErrorWebpTooLarge},
+		{"frame outside canvas", animatedWebp(75, 100, outside), ErrorInvalidWebp},
+		{"frame offset overflow", animatedWebp(75, 100, testFrame{X: 1 << 24, Width: 75, Height: 100, Data: lossless}), ErrorInvalidWebp},
+		{"frame size mismatch", animatedWebp(75, 100, wrongSize), ErrorInvalidWebp},
+		{"short ANMF", encodeWebpChunks([]webpChunk{newVP8X(75, 100, vp8xFlagAnimation), {FourCC: "ANMF", Payload: []byte{1, 2, 3}}}), ErrorInvalidWebp},
+		{"too many frames", animatedWebp(75, 100, manyFrames...), ErrorWebpTooLarge},
+		{"truncated file", readSample(t, "lossy.webp")[:40], ErrorInvalidWebp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecodeWebpFrames(tt.data)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WebP files are RIFF containers: "RIFF" <size> "WEBP" followed by chunks of
+// <fourcc> <size> <payload> [pad byte]. Metadata such as EXIF can only be
+// attached to the extended format, which starts with a VP8X chunk whose flags
+// announce the optional chunks present in the file.
+
+var ErrorInvalidWebp = errors.New("invalid webp file")
+
+const (
+	vp8xFlagAnimation = 0x02
+	vp8xFlagXMP       = 0x04
+	vp8xFlagEXIF      = 0x08
+	vp8xFlagAlpha     = 0x10
+	vp8xFlagICC       = 0x20
+)
+
+type webpChunk struct {
+	FourCC  string
+	Payload []byte
+}
+
+func parseWebpChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrorInvalidWebp
+	}
+
+	end := 8 + int(binary.LittleEndian.Uint32(data[4:8]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	var chunks []webpChunk
+	for offset := 12; offset+8 <= end; {
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		start := offset + 8
+		if size < 0 || start+size > end {
+			return nil, fmt.Errorf("%w: chunk %q overflows the file", ErrorInvalidWebp, data[offset:offset+4])
+		}
+
+		chunks = append(chunks, webpChunk{
+			FourCC:  string(data[offset : offset+4]),
+			Payload: data[start : start+size],
+		})
+		offset = start + size + size%2
+	}
+
+	if len(chunks) == 0 {
+		return nil, ErrorInvalidWebp
+	}
+	return chunks, nil
+}
+
+func encodeWebpChunks(chunks []webpChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, chunk := range chunks {
+		var header [8]byte
+		copy(header[0:4], chunk.FourCC)
+		binary.LittleEndian.PutUint32(header[4:8], uint32(len(chunk.Payload)))
+		body.Write(header[:])
+		body.Write(chunk.Payload)
+		if len(chunk.Payload)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	out := make([]byte, 8, 8+body.Len())
+	copy(out[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+// webpCanvasSize reads the dimensions and alpha usage of a simple (VP8/VP8L)
+// WebP bitstream, which are needed to build its VP8X header.
+func webpCanvasSize(chunk webpChunk) (int, int, bool, error) {
+	p := chunk.Payload
+	switch chunk.FourCC {
+	case "VP8 ":
+		if len(p) < 10 || p[3] != 0x9d || p[4] != 0x01 || p[5] != 0x2a {
+			return 0, 0, false, fmt.Errorf("%w: bad VP8 frame header", ErrorInvalidWebp)
+		}
+		width := int(binary.LittleEndian.Uint16(p[6:8]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(p[8:10]) & 0x3fff)
+		return width, height, false, nil
+	case "VP8L":
+		if len(p) < 5 || p[0] != 0x2f {
+			return 0, 0, false, fmt.Errorf("%w: bad VP8L signature", ErrorInvalidWebp)
+		}
+		bits := binary.LittleEndian.Uint32(p[1:5])
+		width := int(bits&0x3fff) + 1
+		height := int((bits>>14)&0x3fff) + 1
+		hasAlpha := (bits>>28)&1 == 1
+		return width, height, hasAlpha, nil
+	}
+	return 0, 0, false, fmt.Errorf("%w: unexpected %q chunk", ErrorInvalidWebp, chunk.FourCC)
+}
+
+func newVP8X(width, height int, flags byte) webpChunk {
+	payload := make([]byte, 10)
+	payload[0] = flags
+	putUint24(payload[4:7], uint32(width-1))
+	putUint24(payload[7:10], uint32(height-1))
+	return webpChunk{FourCC: "VP8X", Payload: payload}
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// SetWebpExif inserts or replaces the EXIF chunk of a WebP file without
+// touching the image data. Simple VP8/VP8L files are upgraded to the
+// extended VP8X format with the EXIF flag set.
+func SetWebpExif(data, exif []byte) ([]byte, error) {
+	chunks, err := parseWebpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunks[0].FourCC != "VP8X" {
+		width, height, hasAlpha, err := webpCanvasSize(chunks[0])
+		if err != nil {
+			return nil, err
+		}
+		var flags byte
+		if hasAlpha {
+			flags |= vp8xFlagAlpha
+		}
+		chunks = append([]webpChunk{newVP8X(width, height, flags)}, chunks...)
+	} else if len(chunks[0].Payload) < 10 {
+		return nil, fmt.Errorf("%w: short VP8X chunk", ErrorInvalidWebp)
+	}
+
+	// EXIF goes after the image data and before XMP.
+	var result []webpChunk
+	var xmp []webpChunk
+	for _, chunk := range chunks {
+		switch chunk.FourCC {
+		case "EXIF":
+		case "XMP ":
+			xmp = append(xmp, chunk)
+		default:
+			result = append(result, chunk)
+		}
+	}
+	result = append(result, webpChunk{FourCC: "EXIF", Payload: exif})
+	result = append(result, xmp...)
+
+	vp8x := make([]byte, len(result[0].Payload))
+	copy(vp8x, result[0].Payload)
+	vp8x[0] |= vp8xFlagEXIF
+	result[0].Payload = vp8x
+
+	return encodeWebpChunks(result), nil
+}
+
+// GetWebpExif returns the EXIF chunk of a WebP file, if it has one.
+func GetWebpExif(data []byte) ([]byte, bool, error) {
+	chunks, err := parseWebpChunks(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, chunk := range chunks {
+		if chunk.FourCC == "EXIF" {
+			return chunk.Payload, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// IsAnimatedWebp reports whether the VP8X header has the animation flag set.
+func IsAnimatedWebp(data []byte) bool {
+	chunks, err := parseWebpChunks(data)
+	if err != nil || chunks[0].FourCC != "VP8X" || len(chunks[0].Payload) == 0 {
+		return false
+	}
+	return chunks[0].Payload[0]&vp8xFlagAnimation != 0
+}
+
+// The sticker EXIF blob is a little-endian TIFF header with a single IFD
+// entry (tag 0x5741, type UNDEFINED) whose value is the metadata JSON.
+var (
+	stickerExifHeader = []byte{0x49, 0x49, 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x41, 0x57, 0x07, 0x00}
+	stickerExifOffset = []byte{0x16, 0x00, 0x00, 0x00}
+)
+
+type stickerExifJSON struct {
+	PackID    string   `json:"sticker-pack-id"`
+	PackName  string   `json:"sticker-pack-name"`
+	Publisher string   `json:"sticker-pack-publisher"`
+	Emojis    []string `json:"emojis,omitempty"`
+}
+
+func BuildStickerExif(metadata StickerMetadata) ([]byte, error) {
+	jsonBytes, err := json.Marshal(stickerExifJSON{
+		PackID:    metadata.PackID,
+		PackName:  metadata.PackName,
+		Publisher: metadata.Publisher,
+		Emojis:    metadata.Emojis,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	lenBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenBuf, uint32(len(jsonBytes)))
+
+	b.Write(stickerExifHeader)
+	b.Write(lenBuf)
+	b.Write(stickerExifOffset)
+	b.Write(jsonBytes)
+	return b.Bytes(), nil
+}
+
+// ParseStickerExif reads the metadata JSON back from a sticker EXIF blob.
+func ParseStickerExif(exif []byte) (StickerMetadata, error) {
+	var metadata StickerMetadata
+	if len(exif) < len(stickerExifHeader)+8 || !bytes.Equal(exif[:len(stickerExifHeader)], stickerExifHeader) {
+		return metadata, errors.New("not a sticker exif")
+	}
+
+	size := int(binary.LittleEndian.Uint32(exif[14:18]))
+	offset := int(binary.LittleEndian.Uint32(exif[18:22]))
+	if offset+size > len(exif) {
+		return metadata, errors.New("sticker exif is truncated")
+	}
+
+	var parsed stickerExifJSON
+	if err := json.Unmarshal(exif[offset:offset+size], &parsed); err != nil {
+		return metadata, err
+	}
+
+	return StickerMetadata{
+		PackID:    parsed.PackID,
+		PackName:  parsed.PackName,
+		Publisher: parsed.Publisher,
+		Emojis:    parsed.Emojis,
+	}, nil
+}
+
+// ReadWebpMetadata returns the sticker metadata stored in a WebP file.
+func ReadWebpMetadata(data []byte) (StickerMetadata, bool, error) {
+	exif, found, err := GetWebpExif(data)
+	if err != nil || !found {
+		return StickerMetadata{}, false, err
+	}
+
+	metadata, err := ParseStickerExif(exif)
+	return metadata, err == nil, err
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStickerExifRoundTrip(t *testing.T) {
+	tests := []StickerMetadata{
+		{PackID: "wa-bot", PackName: "My Pack", Publisher: "Me"},
+		{PackName: "Stiker 🐱", Publisher: "Saya", Emojis: []string{"😺", "🐱"}},
+		{},
+	}
+
+	for _, metadata := range tests {
+		exif, err := BuildStickerExif(metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseStickerExif(exif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, metadata) {
+			t.Errorf("got %+v, want %+v", got, metadata)
+		}
+	}
+}
+
+func TestParseStickerExifRejects(t *testing.T) {
+	exif, err := BuildStickerExif(StickerMetadata{PackName: "pack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"empty":      nil,
+		"not tiff":   []byte("this is not an exif blob at all"),
+		"truncated":  exif[:len(exif)-3],
+		"bad header": append([]byte{0x4d, 0x4d}, exif[2:]...),
+	}
+	for name, data := range tests {
+		if _, err := ParseStickerExif(data); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+}
+
+func TestSetWebpExif(t *testing.T) {
+	metadata := StickerMetadata{PackID: "wa-bot", PackName: "My Pack", Publisher: "Me", Emojis: []string{"😺"}}
+	exif, err := BuildStickerExif(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		width      int
+		height     int
+		wantFlags  byte
+		bitstreams []string
+	}{
+		{"VP8 gets a synthesized VP8X", readSample(t, "lossy.webp"), 150, 100, vp8xFlagEXIF, []string{"VP8 "}},
+		{"VP8L gets a synthesized VP8X", readSample(t, "lossless.webp"), 75, 100, vp8xFlagEXIF, []string{"VP8L"}},
+		{"animated VP8X is kept", sampleAnimatedWebp(t), 150, 100, vp8xFlagEXIF | vp8xFlagAnimation | vp8xFlagAlpha, []string{"ANIM", "ANMF", "ANMF"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withExif, err := SetWebpExif(tt.data, exif)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, found, err := ReadWebpMetadata(withExif)
+			if err != nil || !found {
+				t.Fatalf("metadata not found: %v", err)
+			}
+			if !reflect.DeepEqual(got, metadata) {
+				t.Errorf("got %+v, want %+v", got, metadata)
+			}
+
+			chunks, err := parseWebpChunks(withExif)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vp8x := chunks[0]
+			if vp8x.FourCC != "VP8X" || len(vp8x.Payload) != 10 {
+				t.Fatalf("first chunk is %q", vp8x.FourCC)
+			}
+			if vp8x.Payload[0] != tt.wantFlags {
+				t.Errorf("flags %#x, want %#x", vp8x.Payload[0], tt.wantFlags)
+			}
+			width := int(uint24(vp8x.Payload[4:7])) + 1
+			height := int(uint24(vp8x.Payload[7:10])) + 1
+			if width != tt.width || height != tt.height {
+				t.Errorf("canvas %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+
+			// The image chunks are untouched and EXIF comes after them.
+			original, err := parseWebpChunks(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fourCCs []string
+			for _, chunk := range chunks[1:] {
+				fourCCs = append(fourCCs, chunk.FourCC)
+			}
+			if want := append(tt.bitstreams, "EXIF"); !reflect.DeepEqual(fourCCs, want) {
+				t.Fatalf("chunks %q, want %q", fourCCs, want)
+			}
+			last := original[len(original)-1]
+			if !bytes.Equal(chunks[len(chunks)-2].Payload, last.Payload) {
+				t.Error("image data changed")
+			}
+
+			if _, _, err := DecodeWebpFrames(withExif); err != nil {
+				t.Errorf("result does not decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetWebpExifReplaces(t *testing.T) {
+	first, _ := BuildStickerExif(StickerMetadata{PackName: "first"})
+	second, _ := BuildStickerExif(StickerMetadata{PackName: "second"})
+	xmp := webpChunk{FourCC: "XMP ", Payload: []byte("<x:xmpmeta/>")}
+
+	chunks, err := parseWebpChunks(sampleAnimatedWebp(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := encodeWebpChunks(append(chunks, xmp))
+
+	for _, exif := range [][]byte{first, second} {
+		if data, err = SetWebpExif(data, exif); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunks, err = parseWebpChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exifCount := 0
+	for _, chunk := range chunks {
+		if chunk.FourCC == "EXIF" {
+			exifCount++
+		}
+	}
+	if exifCount != 1 || chunks[len(chunks)-2].FourCC != "EXIF" || chunks[len(chunks)-1].FourCC != "XMP " {
+		t.Fatalf("EXIF chunks %d, ending with %q %q", exifCount, chunks[len(chunks)-2].FourCC, chunks[len(chunks)-1].FourCC)
+	}
+
+	metadata, _, err := ReadWebpMetadata(data)
+	if err != nil || metadata.PackName != "second" {
+		t.Fatalf("got %+v, %v", metadata, err)
+	}
+}
+
+func TestGetWebpExif(t *testing.T) {
+	if _, found, err := GetWebpExif(readSample(t, "lossy.webp")); found || err != nil {
+		t.Errorf("found=%v err=%v on a file without EXIF", found, err)
+	}
+	if _, _, err := GetWebpExif([]byte("RIFF\x04\x00\x00\x00WEBP")); !errors.Is(err, ErrorInvalidWebp) {
+		t.Errorf("got %v on an empty WebP", err)
+	}
+
+	short := encodeWebpChunks([]webpChunk{{FourCC: "VP8X", Payload: []byte{0}}})
+	if _, err := SetWebpExif(short, nil); !errors.Is(err, ErrorInvalidWebp) {
+		t.Errorf("got %v on a short VP8X", err)
+	}
+}